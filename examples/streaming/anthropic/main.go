@@ -0,0 +1,56 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	"github.com/instructor-ai/instructor-go/pkg/instructor"
+	anthropic "github.com/liushuangls/go-anthropic/v2"
+)
+
+type HistoricalFact struct {
+	Decade      string `json:"decade"       jsonschema:"title=Decade of the Fact,description=Decade when the fact occurred"`
+	Topic       string `json:"topic"        jsonschema:"title=Topic of the Fact,description=General category or topic of the fact"`
+	Description string `json:"description"  jsonschema:"title=Description of the Fact,description=Description or details of the fact"`
+}
+
+func (hf HistoricalFact) String() string {
+	return fmt.Sprintf(`
+Decade:         %s
+Topic:          %s
+Description:    %s`, hf.Decade, hf.Topic, hf.Description)
+}
+
+func main() {
+	ctx := context.Background()
+
+	client := instructor.FromAnthropic(
+		anthropic.NewClient(os.Getenv("ANTHROPIC_API_KEY")),
+		instructor.WithMode(instructor.ModeJSONSchema),
+		instructor.WithMaxRetries(3),
+	)
+
+	hfStream, err := client.CreateMessagesStream(ctx, anthropic.MessagesStreamRequest{
+		MessagesRequest: anthropic.MessagesRequest{
+			Model: anthropic.ModelClaude3Haiku20240307,
+			Messages: []anthropic.Message{
+				anthropic.NewUserTextMessage("Tell me about the history of artificial intelligence up to year 2000"),
+			},
+			MaxTokens: 2500,
+		},
+	},
+		*new(HistoricalFact),
+	)
+	if err != nil {
+		panic(err)
+	}
+
+	for instance := range hfStream.Items() {
+		hf := instance.(*HistoricalFact)
+		println(hf.String())
+	}
+	if err := hfStream.Err(); err != nil {
+		panic(err)
+	}
+}
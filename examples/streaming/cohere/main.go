@@ -43,10 +43,13 @@ func main() {
 		panic(err)
 	}
 
-	for instance := range hfStream {
+	for instance := range hfStream.Items() {
 		hf := instance.(*HistoricalFact)
 		println(hf.String())
 	}
+	if err := hfStream.Err(); err != nil {
+		panic(err)
+	}
 	/*
 	   Decade:         1950s
 	   Topic:          Birth of AI
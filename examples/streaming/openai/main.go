@@ -71,7 +71,7 @@ Preferred Shopping Times: Weekend Evenings
 		productList += product.String() + "\n"
 	}
 
-	recommendationChan, err := client.CreateChatCompletionStream(ctx, openai.ChatCompletionRequest{
+	recommendationStream, err := client.CreateChatCompletionStream(ctx, openai.ChatCompletionRequest{
 		Model: openai.GPT4o20240513,
 		Messages: []openai.ChatCompletionMessage{
 			{
@@ -95,10 +95,13 @@ Product list:
 		panic(err)
 	}
 
-	for instance := range recommendationChan {
+	for instance := range recommendationStream.Items() {
 		recommendation, _ := instance.(*Recommendation)
 		println(recommendation.String())
 	}
+	if err := recommendationStream.Err(); err != nil {
+		panic(err)
+	}
 	/*
 		Recommendation [
 		    Product [ID: 7, Name: Apple MacBook Air (2023) - Latest model, high performance, portable]
@@ -0,0 +1,77 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/instructor-ai/instructor-go/pkg/instructor"
+	openai "github.com/sashabaranov/go-openai"
+)
+
+type Ticket struct {
+	Name        string   `json:"name"        jsonschema:"title=name of the task,description=Title of the task"`
+	Description string   `json:"description" jsonschema:"title=description of the task,description=Detailed description of the task"`
+	Assignees   []string `json:"assignees"   jsonschema:"title=list of users assigned to the task,description=List of users assigned to the task"`
+}
+
+type ActionItems struct {
+	Tickets []Ticket `json:"tickets"`
+}
+
+func main() {
+	ctx := context.Background()
+
+	client := instructor.FromOpenAI(
+		openai.NewClient(os.Getenv("OPENAI_API_KEY")),
+		instructor.WithMode(instructor.ModeJSON),
+	)
+
+	transcript := `
+Alice: Hey team, we have several critical tasks we need to tackle for the upcoming release. First, we need to work on improving the authentication system. It's a top priority.
+
+Bob: Got it, Alice. I can take the lead on the authentication improvements. Are there any specific areas you want me to focus on?
+
+Alice: Good question, Bob. We need both a front-end revamp and back-end optimization. So basically, two sub-tasks.
+
+Carol: I can help with the front-end part of the authentication system.
+
+Alice: Thanks, Carol. Next, the performance of our database queries has been lagging. Bob, can you also look into that after the authentication improvements?
+`
+
+	partials, err := client.CreateChatCompletionPartialStream(ctx, openai.ChatCompletionRequest{
+		Model: openai.GPT4o20240513,
+		Messages: []openai.ChatCompletionMessage{
+			{
+				Role:    openai.ChatMessageRoleUser,
+				Content: "Create the action items for the following transcript:\n" + transcript,
+			},
+		},
+		Stream: true,
+	},
+		*new(ActionItems),
+	)
+	if err != nil {
+		panic(err)
+	}
+
+	for partial := range partials.Items() {
+		actionItems := partial.Value.(*ActionItems)
+
+		// render the tickets as they are generated, marking the finished ones
+		var sb strings.Builder
+		for idx, ticket := range actionItems.Tickets {
+			status := "..."
+			if partial.IsComplete(fmt.Sprintf("tickets[%d]", idx)) {
+				status = "done"
+			}
+			sb.WriteString(fmt.Sprintf("[%s] %s: %s (%s)\n", status, ticket.Name, ticket.Description, strings.Join(ticket.Assignees, ", ")))
+		}
+
+		fmt.Print("\033[H\033[2J" + sb.String())
+	}
+	if err := partials.Err(); err != nil {
+		panic(err)
+	}
+}
@@ -0,0 +1,78 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+
+	"github.com/instructor-ai/instructor-go/pkg/instructor"
+	openai "github.com/sashabaranov/go-openai"
+)
+
+type Keyword struct {
+	Word      string  `json:"word"      jsonschema:"title=Word,description=Keyword of the text"`
+	Relevance float64 `json:"relevance" jsonschema:"title=Relevance,description=Relevance of the keyword from 0 to 1"`
+}
+
+func main() {
+	client := instructor.FromOpenAI(
+		openai.NewClient(os.Getenv("OPENAI_API_KEY")),
+		instructor.WithMode(instructor.ModeJSON),
+	)
+
+	// Server: relays the extraction stream to the browser
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		stream, err := client.CreateChatCompletionStream(r.Context(), openai.ChatCompletionRequest{
+			Model: openai.GPT4o20240513,
+			Messages: []openai.ChatCompletionMessage{
+				{
+					Role:    openai.ChatMessageRoleUser,
+					Content: "Extract the keywords of: " + r.URL.Query().Get("text"),
+				},
+			},
+			Stream: true,
+		},
+			*new(Keyword),
+		)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadGateway)
+			return
+		}
+
+		if err := instructor.WriteSSE(r.Context(), w, stream); err != nil {
+			fmt.Println("client went away:", err)
+		}
+	})
+
+	server := httptest.NewServer(handler)
+	defer server.Close()
+
+	// Client: decodes the events
+	resp, err := http.Get(server.URL + "?text=Go+channels+make+concurrent+pipelines+easy+to+build")
+	if err != nil {
+		panic(err)
+	}
+	defer resp.Body.Close()
+
+	decoder := instructor.NewSSEDecoder[Keyword](resp.Body)
+	for {
+		event, err := decoder.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			panic(err)
+		}
+
+		switch event.Type {
+		case instructor.SSEEventItem:
+			fmt.Printf("%s (%.2f)\n", event.Item.Word, event.Item.Relevance)
+		case instructor.SSEEventError:
+			fmt.Println("error:", event.Error.Message)
+		case instructor.SSEEventUsage:
+			fmt.Printf("used %d input and %d output tokens\n", event.Usage.InputTokens, event.Usage.OutputTokens)
+		}
+	}
+}
@@ -76,10 +76,7 @@ func extract(ctx context.Context, client *instructor.InstructorOpenAI, url strin
 		&receipt,
 	)
 	if err != nil {
-		return nil, err
-	}
-
-	if err := receipt.Validate(); err != nil {
+		// Receipt.Validate() runs on every attempt, so this is the last validation error after retries
 		return &receipt, err
 	}
 
@@ -93,6 +90,8 @@ func main() {
 		openai.NewClient(os.Getenv("OPENAI_API_KEY")),
 		instructor.WithMode(instructor.ModeJSON),
 		instructor.WithMaxRetries(3),
+		// runs Receipt.Validate() on every attempt
+		instructor.WithValidation(),
 	)
 
 	urls := []string{
@@ -109,7 +108,7 @@ func main() {
 			fmt.Printf("Error: %v\n", err)
 			continue
 		}
-		fmt.Println("\n--------------------------------\n")
+		fmt.Print("\n--------------------------------\n\n")
 	}
 	/*
 	   Receipt:
@@ -6,7 +6,7 @@ require (
 	github.com/cohere-ai/cohere-go/v2 v2.8.1
 	github.com/go-playground/validator/v10 v10.21.0
 	github.com/invopop/jsonschema v0.12.0
-	github.com/liushuangls/go-anthropic/v2 v2.1.0
+	github.com/liushuangls/go-anthropic/v2 v2.10.0
 	github.com/sashabaranov/go-openai v1.29.0
 )
 
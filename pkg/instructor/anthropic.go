@@ -6,41 +6,27 @@ import (
 
 type InstructorAnthropic struct {
 	*anthropic.Client
-
-	provider   Provider
-	mode       Mode
-	maxRetries int
-	validate   bool
+	clientOptions
 }
 
 var _ Instructor = &InstructorAnthropic{}
 
 func FromAnthropic(client *anthropic.Client, opts ...Options) *InstructorAnthropic {
-
-	options := mergeOptions(opts...)
-
-	i := &InstructorAnthropic{
-		Client: client,
-
-		provider:   ProviderOpenAI,
-		mode:       *options.Mode,
-		maxRetries: *options.MaxRetries,
-		validate:   *options.validate,
+	return &InstructorAnthropic{
+		Client:        client,
+		clientOptions: newClientOptions(ProviderAnthropic, opts...),
 	}
-	return i
 }
 
-func (i *InstructorAnthropic) MaxRetries() int {
-	return i.maxRetries
-}
-
-func (i *InstructorAnthropic) Mode() string {
-	return i.mode
+// Copy of the client with per-call options merged over its options
+func (i *InstructorAnthropic) withOptions(opts ...Options) *InstructorAnthropic {
+	if len(opts) == 0 {
+		return i
+	}
+	return &InstructorAnthropic{Client: i.Client, clientOptions: i.with(opts...)}
 }
 
-func (i *InstructorAnthropic) Provider() string {
-	return i.provider
-}
-func (i *InstructorAnthropic) Validate() bool {
-	return i.validate
+// Copy running the secondary calls of LLM validation, see Options.forValidation
+func (i *InstructorAnthropic) validationInstructor() Instructor {
+	return &InstructorAnthropic{Client: i.Client, clientOptions: i.forValidation()}
 }
@@ -9,9 +9,9 @@ import (
 	anthropic "github.com/liushuangls/go-anthropic/v2"
 )
 
-func (i *InstructorAnthropic) CreateMessages(ctx context.Context, request anthropic.MessagesRequest, responseType any) (response anthropic.MessagesResponse, err error) {
+func (i *InstructorAnthropic) CreateMessages(ctx context.Context, request anthropic.MessagesRequest, responseType any, opts ...Options) (response anthropic.MessagesResponse, err error) {
 
-	resp, err := chatHandler(i, ctx, request, responseType)
+	resp, err := chatHandler(i.withOptions(opts...), ctx, request, responseType)
 	if err != nil {
 		if resp == nil {
 			return anthropic.MessagesResponse{}, err
@@ -32,21 +32,71 @@ func (i *InstructorAnthropic) chat(ctx context.Context, request interface{}, sch
 	}
 
 	if req.Stream {
-		return "", nil, errors.New("streaming is not supported by this method; use CreateChatCompletionStream instead")
+		return "", nil, errors.New("streaming is not supported by this method; use CreateMessagesStream instead")
 	}
 
 	switch i.Mode() {
 	case ModeToolCall:
 		return i.completionToolCall(ctx, &req, schema)
+	case ModeJSON:
+		return i.completionJSON(ctx, &req, schema)
 	case ModeJSONSchema:
 		return i.completionJSONSchema(ctx, &req, schema)
+	case ModeMarkdownJSON:
+		return i.completionMarkdownJSON(ctx, &req, schema)
 	default:
-		return "", nil, fmt.Errorf("mode '%s' is not supported for %s", i.Mode(), i.Provider())
+		return "", nil, &UnsupportedModeError{Provider: i.Provider(), Mode: i.Mode()}
 	}
 }
 
 func (i *InstructorAnthropic) completionToolCall(ctx context.Context, request *anthropic.MessagesRequest, schema *Schema) (string, *anthropic.MessagesResponse, error) {
 
+	i.addTools(request, schema)
+
+	resp, err := i.Client.CreateMessages(ctx, *request)
+	if err != nil {
+		return "", nil, newProviderError(i.Provider(), err)
+	}
+
+	toolInputs := []json.RawMessage{}
+	for _, c := range resp.Content {
+		if c.Type != anthropic.MessagesContentTypeToolUse || c.MessageContentToolUse == nil {
+			// Skip non tool responses
+			continue
+		}
+
+		toolInput, err := json.Marshal(c.Input)
+		if err != nil {
+			return "", nilAnthropicRespWithUsage(&resp), err
+		}
+		toolInputs = append(toolInputs, toolInput)
+	}
+
+	numTools := len(toolInputs)
+
+	if numTools < 1 {
+		// the text the model answered instead usually explains why
+		text, _ := anthropicResponseText(&resp)
+		return text, &resp, &DecodeError{Text: text, Err: errNoToolCalls}
+	}
+
+	// list responses are an array even when made of a single tool call
+	if numTools == 1 && schema.Type != "array" {
+		return string(toolInputs[0]), &resp, nil
+	}
+
+	// numTools > 1, or a list response
+
+	resultJSON, err := json.Marshal(toolInputs)
+	if err != nil {
+		return "", nilAnthropicRespWithUsage(&resp), err
+	}
+
+	return string(resultJSON), &resp, nil
+}
+
+func (i *InstructorAnthropic) addTools(request *anthropic.MessagesRequest, schema *Schema) {
+
 	request.Tools = []anthropic.ToolDefinition{}
 
 	for _, function := range schema.Functions {
@@ -58,30 +108,62 @@ func (i *InstructorAnthropic) completionToolCall(ctx context.Context, request *a
 		request.Tools = append(request.Tools, t)
 	}
 
+	if i.ForceToolCall() && request.ToolChoice == nil {
+		request.ToolChoice = anthropicToolChoice(schema)
+	}
+}
+
+// Makes the model call the tool of the response, or any of the tools for
+// responses made of several tool calls
+func anthropicToolChoice(schema *Schema) *anthropic.ToolChoice {
+	if name := forcedToolName(schema); name != "" {
+		return &anthropic.ToolChoice{Type: "tool", Name: name}
+	}
+	return &anthropic.ToolChoice{Type: "any"}
+}
+
+// Prefills the assistant turn with the opening brace or bracket of the JSON,
+// so the model continues the JSON instead of starting with prose
+func (i *InstructorAnthropic) completionJSON(ctx context.Context, request *anthropic.MessagesRequest, schema *Schema) (string, *anthropic.MessagesResponse, error) {
+
+	addJSONSchemaSystemPrompt(request, schema)
+
+	prefill := jsonPrefill(schema)
+
+	request.Messages = appendCopy(request.Messages, anthropic.NewAssistantTextMessage(prefill))
+
 	resp, err := i.Client.CreateMessages(ctx, *request)
 	if err != nil {
-		return "", nil, err
+		return "", nil, newProviderError(i.Provider(), err)
 	}
 
-	for _, c := range resp.Content {
-		if c.Type != anthropic.MessagesContentTypeToolUse {
-			// Skip non tool responses
-			continue
-		}
+	text, err := anthropicResponseText(&resp)
+	if err != nil {
+		return "", &resp, err
+	}
 
-		toolInput, err := json.Marshal(c.Input)
-		if err != nil {
-			return "", nilAnthropicRespWithUsage(&resp), err
-		}
-		// TODO: handle more than 1 tool use
-		return string(toolInput), &resp, nil
+	// the completion continues the prefill
+	return prefill + text, &resp, nil
+}
+
+func (i *InstructorAnthropic) completionJSONSchema(ctx context.Context, request *anthropic.MessagesRequest, schema *Schema) (string, *anthropic.MessagesResponse, error) {
+
+	addJSONSchemaSystemPrompt(request, schema)
+
+	resp, err := i.Client.CreateMessages(ctx, *request)
+	if err != nil {
+		return "", nil, newProviderError(i.Provider(), err)
 	}
 
-	return "", nilAnthropicRespWithUsage(&resp), errors.New("more than 1 tool response at a time is not implemented")
+	text, err := anthropicResponseText(&resp)
+	if err != nil {
+		return "", &resp, err
+	}
 
+	return text, &resp, nil
 }
 
-func (i *InstructorAnthropic) completionJSONSchema(ctx context.Context, request *anthropic.MessagesRequest, schema *Schema) (string, *anthropic.MessagesResponse, error) {
+func addJSONSchemaSystemPrompt(request *anthropic.MessagesRequest, schema *Schema) {
 
 	system := fmt.Sprintf(`
 Please responsd with json in the following json_schema:
@@ -96,15 +178,111 @@ Make sure to return an instance of the JSON, not the schema itself.
 	} else {
 		request.System += system
 	}
+}
+
+// Opening character of the JSON of the schema, "[" for list types
+func jsonPrefill(schema *Schema) string {
+	if schema.Type == "array" {
+		return "["
+	}
+	return "{"
+}
+
+// Returns the text of the first text block, the content may start with other
+// blocks, ex: tool_use or thinking. A response without text is a *DecodeError.
+func anthropicResponseText(resp *anthropic.MessagesResponse) (string, error) {
+	for _, c := range resp.Content {
+		if c.Type == anthropic.MessagesContentTypeText && c.Text != nil {
+			return *c.Text, nil
+		}
+	}
+
+	return "", &DecodeError{Err: errors.New("received no text content from model, expected at least 1 text block")}
+}
+
+func (i *InstructorAnthropic) completionMarkdownJSON(ctx context.Context, request *anthropic.MessagesRequest, schema *Schema) (string, *anthropic.MessagesResponse, error) {
+
+	if request.System == "" {
+		request.System = markdownJSONPrompt(schema)
+	} else {
+		request.System += markdownJSONPrompt(schema)
+	}
 
 	resp, err := i.Client.CreateMessages(ctx, *request)
 	if err != nil {
-		return "", nil, err
+		return "", nil, newProviderError(i.Provider(), err)
+	}
+
+	text, err := anthropicResponseText(&resp)
+	if err != nil {
+		return "", &resp, err
+	}
+
+	return text, &resp, nil
+}
+
+func (i *InstructorAnthropic) setModel(request interface{}, model string) interface{} {
+	req, ok := request.(anthropic.MessagesRequest)
+	if !ok {
+		return request
 	}
+	req.Model = anthropic.Model(model)
+	return req
+}
 
-	text := resp.Content[0].Text
+func (i *InstructorAnthropic) validationRequest(request interface{}, prompt string) interface{} {
+	req, _ := request.(anthropic.MessagesRequest)
 
-	return *text, &resp, nil
+	return anthropic.MessagesRequest{
+		Model:     req.Model,
+		MaxTokens: req.MaxTokens,
+		Messages: []anthropic.Message{
+			anthropic.NewUserTextMessage(prompt),
+		},
+	}
+}
+
+func (i *InstructorAnthropic) addReaskMessages(request interface{}, response interface{}, text string, err error) interface{} {
+	req, ok := request.(anthropic.MessagesRequest)
+	if !ok {
+		return request
+	}
+
+	resp, ok := response.(*anthropic.MessagesResponse)
+	if !ok || resp == nil || len(resp.Content) == 0 {
+		return request
+	}
+
+	assistant := anthropic.Message{
+		Role:    anthropic.RoleAssistant,
+		Content: resp.Content,
+	}
+	if i.Mode() == ModeJSON {
+		// the content lacks the prefill, send back the whole JSON
+		assistant.Content = []anthropic.MessageContent{anthropic.NewTextMessageContent(text)}
+	}
+
+	// every tool_use block must be answered by a tool_result block
+	results := []anthropic.MessageContent{}
+	for _, c := range resp.Content {
+		if c.Type != anthropic.MessagesContentTypeToolUse || c.MessageContentToolUse == nil {
+			continue
+		}
+		results = append(results, anthropic.NewToolResultMessageContent(c.ID, reaskPrompt(err), true))
+	}
+
+	if len(results) == 0 {
+		results = append(results, anthropic.NewTextMessageContent(reaskPrompt(err)))
+	}
+
+	user := anthropic.Message{
+		Role:    anthropic.RoleUser,
+		Content: results,
+	}
+
+	req.Messages = appendCopy(req.Messages, assistant, user)
+
+	return req
 }
 
 func (i *InstructorAnthropic) emptyResponseWithUsageSum(usage *UsageSum) interface{} {
@@ -141,7 +319,7 @@ func (i *InstructorAnthropic) addUsageSumToResponse(response interface{}, usage
 
 func (i *InstructorAnthropic) countUsageFromResponse(response interface{}, usage *UsageSum) *UsageSum {
 	resp, ok := response.(*anthropic.MessagesResponse)
-	if !ok {
+	if !ok || resp == nil {
 		return usage
 	}
 
@@ -2,8 +2,204 @@ package instructor
 
 import (
 	"context"
+	"fmt"
+	"sync"
+
+	anthropic "github.com/liushuangls/go-anthropic/v2"
 )
 
-func (i *InstructorAnthropic) chatStream(ctx context.Context, request interface{}, schema *Schema) (<-chan string, error) {
-	panic("unimplemented")
+func (i *InstructorAnthropic) CreateMessagesStream(
+	ctx context.Context,
+	request anthropic.MessagesStreamRequest,
+	responseType any,
+	opts ...Options,
+) (*Stream[any], error) {
+	return chatStreamHandler(i.withOptions(opts...), ctx, request, responseType)
+}
+
+// CreateMessagesPartialStream streams progressively filled copies of each response, see PartialResponse
+func (i *InstructorAnthropic) CreateMessagesPartialStream(
+	ctx context.Context,
+	request anthropic.MessagesStreamRequest,
+	responseType any,
+	opts ...Options,
+) (*Stream[*PartialResponse], error) {
+	return chatPartialStreamHandler(i.withOptions(opts...), ctx, request, responseType)
+}
+
+func (i *InstructorAnthropic) chatStream(ctx context.Context, request interface{}, schema *Schema) (<-chan streamChunk, error) {
+
+	req, ok := request.(anthropic.MessagesStreamRequest)
+	if !ok {
+		return nil, fmt.Errorf("invalid request type for %s client", i.Provider())
+	}
+
+	switch i.Mode() {
+	case ModeToolCall:
+		return i.completionToolCallStream(ctx, &req, schema)
+	case ModeJSON:
+		return i.completionJSONStream(ctx, &req, schema)
+	case ModeJSONSchema:
+		return i.completionJSONSchemaStream(ctx, &req, schema)
+	case ModeMarkdownJSON:
+		return i.completionMarkdownJSONStream(ctx, &req, schema)
+	default:
+		return nil, &UnsupportedModeError{Provider: i.Provider(), Mode: i.Mode()}
+	}
+}
+
+// The input_json_delta fragments of the tool_use blocks are assembled into
+// the elements of the stream, see toolCallStream
+func (i *InstructorAnthropic) completionToolCallStream(ctx context.Context, request *anthropic.MessagesStreamRequest, schema *Schema) (<-chan streamChunk, error) {
+	i.addTools(&request.MessagesRequest, schema)
+	return i.createStream(ctx, request, schema, "")
+}
+
+// Prefills the assistant turn with the opening brace of the StreamWrapper
+func (i *InstructorAnthropic) completionJSONStream(ctx context.Context, request *anthropic.MessagesStreamRequest, schema *Schema) (<-chan streamChunk, error) {
+
+	addJSONSchemaStreamSystemPrompt(request, schema)
+
+	prefill := "{"
+
+	request.Messages = appendCopy(request.Messages, anthropic.NewAssistantTextMessage(prefill))
+
+	return i.createStream(ctx, request, schema, prefill)
+}
+
+func (i *InstructorAnthropic) completionJSONSchemaStream(ctx context.Context, request *anthropic.MessagesStreamRequest, schema *Schema) (<-chan streamChunk, error) {
+
+	addJSONSchemaStreamSystemPrompt(request, schema)
+
+	return i.createStream(ctx, request, schema, "")
+}
+
+func addJSONSchemaStreamSystemPrompt(request *anthropic.MessagesStreamRequest, schema *Schema) {
+
+	system := fmt.Sprintf(`
+Please respond with a JSON object with an "items" array, where the elements follow this JSON schema:
+
+%s
+
+Make sure to return an array with the elements an instance of the JSON, not the schema itself.
+`, schema.String)
+
+	if request.System == "" {
+		request.System = system
+	} else {
+		request.System += system
+	}
+}
+
+func (i *InstructorAnthropic) completionMarkdownJSONStream(ctx context.Context, request *anthropic.MessagesStreamRequest, schema *Schema) (<-chan streamChunk, error) {
+
+	if request.System == "" {
+		request.System = markdownJSONPrompt(schema)
+	} else {
+		request.System += markdownJSONPrompt(schema)
+	}
+
+	return i.createStream(ctx, request, schema, "")
+}
+
+// Runs the blocking go-anthropic stream in the background, forwarding text
+// deltas after the prefill of the assistant turn, if any, or in ModeToolCall
+// the tool_use inputs. Returns once the stream started, so request errors are
+// returned, errors of a started stream are sent as its last chunk.
+func (i *InstructorAnthropic) createStream(ctx context.Context, request *anthropic.MessagesStreamRequest, schema *Schema, prefill string) (<-chan streamChunk, error) {
+
+	ch := make(chan streamChunk)
+	started := make(chan struct{})
+	done := make(chan error, 1)
+
+	toolCall := i.Mode() == ModeToolCall
+	toolCalls := newToolCallStream(schema.streamWrapper)
+
+	// keep the caller's callbacks working
+	onMessageStart := request.OnMessageStart
+	onContentBlockStart := request.OnContentBlockStart
+	onContentBlockDelta := request.OnContentBlockDelta
+	onMessageDelta := request.OnMessageDelta
+
+	var startOnce sync.Once
+
+	request.OnMessageStart = func(data anthropic.MessagesEventMessageStartData) {
+		if onMessageStart != nil {
+			onMessageStart(data)
+		}
+		startOnce.Do(func() { close(started) })
+
+		// output tokens are counted by the message_delta events, the totals of
+		// both chunks add up to InputTokens + OutputTokens
+		input := data.Message.Usage.InputTokens
+		sendChunk(ctx, ch, streamChunk{Usage: &UsageSum{InputTokens: input, TotalTokens: input}, Text: prefill})
+	}
+
+	request.OnMessageDelta = func(data anthropic.MessagesEventMessageDeltaData) {
+		if onMessageDelta != nil {
+			onMessageDelta(data)
+		}
+		output := data.Usage.OutputTokens
+		sendChunk(ctx, ch, streamChunk{Usage: &UsageSum{OutputTokens: output, TotalTokens: output}})
+	}
+
+	request.OnContentBlockStart = func(data anthropic.MessagesEventContentBlockStartData) {
+		if onContentBlockStart != nil {
+			onContentBlockStart(data)
+		}
+		if !toolCall || data.ContentBlock.Type != anthropic.MessagesContentTypeToolUse {
+			return
+		}
+		// the input follows in input_json_delta events
+		sendChunk(ctx, ch, streamChunk{Text: toolCalls.add(data.Index, "")})
+	}
+
+	request.OnContentBlockDelta = func(data anthropic.MessagesEventContentBlockDeltaData) {
+		if onContentBlockDelta != nil {
+			onContentBlockDelta(data)
+		}
+		switch {
+		case toolCall && data.Delta.PartialJson != nil:
+			sendChunk(ctx, ch, streamChunk{Text: toolCalls.add(data.Index, *data.Delta.PartialJson)})
+		case toolCall:
+			// in ModeToolCall the text is the model's plan, the elements are the tool inputs
+		case data.Delta.Text != nil:
+			sendChunk(ctx, ch, streamChunk{Text: *data.Delta.Text})
+		}
+	}
+
+	go func() {
+		defer close(ch)
+		_, err := i.Client.CreateMessagesStream(ctx, *request)
+		done <- err
+
+		select {
+		case <-started:
+			if err != nil {
+				sendChunk(ctx, ch, streamChunk{Err: newProviderError(i.Provider(), err)})
+			} else {
+				sendChunk(ctx, ch, streamChunk{Text: toolCalls.end()})
+			}
+		default:
+			// the error is returned by createStream
+		}
+	}()
+
+	select {
+	case <-started:
+		return ch, nil
+	case err := <-done:
+		select {
+		case <-started:
+			// the error is sent on the stream
+			return ch, nil
+		default:
+		}
+		if err != nil {
+			return nil, newProviderError(i.Provider(), err)
+		}
+		return ch, nil
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
 }
@@ -0,0 +1,304 @@
+package instructor
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+
+	anthropic "github.com/liushuangls/go-anthropic/v2"
+)
+
+type anthropicTestPerson struct {
+	Name string `json:"name"`
+}
+
+// Parts of the messages requests checked by the tests
+type anthropicTestRequest struct {
+	Messages   []anthropic.Message   `json:"messages"`
+	ToolChoice *anthropic.ToolChoice `json:"tool_choice"`
+}
+
+// Client of a server answering every request with the next of the bodies,
+// the requests it received are appended to requests
+func newAnthropicTestClient(t *testing.T, requests *[]anthropicTestRequest, bodies ...string) *anthropic.Client {
+	t.Helper()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var request anthropicTestRequest
+		if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+			t.Errorf("got error %v decoding the request", err)
+		}
+		if requests != nil {
+			*requests = append(*requests, request)
+		}
+		if len(bodies) == 0 {
+			t.Error("got more requests than responses")
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprint(w, bodies[0])
+		bodies = bodies[1:]
+	}))
+	t.Cleanup(server.Close)
+
+	return anthropic.NewClient("test", anthropic.WithBaseURL(server.URL))
+}
+
+// Body of a messages response with the content blocks
+func anthropicTestContent(content ...anthropic.MessageContent) string {
+	body, _ := json.Marshal(anthropic.MessagesResponse{
+		Type:    anthropic.MessagesResponseTypeMessage,
+		Role:    anthropic.RoleAssistant,
+		Content: content,
+	})
+	return string(body)
+}
+
+func TestAnthropicAddReaskMessages(t *testing.T) {
+	reaskErr := &DecodeError{Err: errors.New("unexpected end of JSON input")}
+	user := anthropic.NewUserTextMessage("person")
+
+	toolUses := []anthropic.MessageContent{
+		anthropic.NewTextMessageContent("Calling the tools"),
+		anthropic.NewToolUseMessageContent("toolu_1", "Person", []byte(`{"name": 1}`)),
+		anthropic.NewToolUseMessageContent("toolu_2", "Person", []byte(`{}`)),
+	}
+
+	tests := []struct {
+		name     string
+		mode     Mode
+		response *anthropic.MessagesResponse
+		want     []anthropic.Message
+	}{
+		{
+			name:     "text",
+			mode:     ModeMarkdownJSON,
+			response: &anthropic.MessagesResponse{Content: []anthropic.MessageContent{anthropic.NewTextMessageContent("```json\n{\"name\":")}},
+			want: []anthropic.Message{
+				user,
+				{Role: anthropic.RoleAssistant, Content: []anthropic.MessageContent{anthropic.NewTextMessageContent("```json\n{\"name\":")}},
+				{Role: anthropic.RoleUser, Content: []anthropic.MessageContent{anthropic.NewTextMessageContent(reaskPrompt(reaskErr))}},
+			},
+		},
+		{
+			name:     "tool_use blocks",
+			mode:     ModeToolCall,
+			response: &anthropic.MessagesResponse{Content: toolUses},
+			want: []anthropic.Message{
+				user,
+				{Role: anthropic.RoleAssistant, Content: toolUses},
+				{Role: anthropic.RoleUser, Content: []anthropic.MessageContent{
+					anthropic.NewToolResultMessageContent("toolu_1", reaskPrompt(reaskErr), true),
+					anthropic.NewToolResultMessageContent("toolu_2", reaskPrompt(reaskErr), true),
+				}},
+			},
+		},
+		{
+			name:     "JSON with prefill",
+			mode:     ModeJSON,
+			response: &anthropic.MessagesResponse{Content: []anthropic.MessageContent{anthropic.NewTextMessageContent(`"name":`)}},
+			want: []anthropic.Message{
+				user,
+				{Role: anthropic.RoleAssistant, Content: []anthropic.MessageContent{anthropic.NewTextMessageContent(`{"name":`)}},
+				{Role: anthropic.RoleUser, Content: []anthropic.MessageContent{anthropic.NewTextMessageContent(reaskPrompt(reaskErr))}},
+			},
+		},
+		{
+			name:     "no content",
+			mode:     ModeToolCall,
+			response: &anthropic.MessagesResponse{},
+			want:     []anthropic.Message{user},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// spare capacity, appending must still copy
+			messages := make([]anthropic.Message, 1, 4)
+			messages[0] = user
+			request := anthropic.MessagesRequest{Model: "test", Messages: messages}
+
+			client := FromAnthropic(nil, WithMode(tt.mode))
+			got := client.addReaskMessages(request, tt.response, `{"name":`, reaskErr).(anthropic.MessagesRequest)
+
+			if !reflect.DeepEqual(got.Messages, tt.want) {
+				t.Errorf("got %+v, want %+v", got.Messages, tt.want)
+			}
+			if extra := messages[:2][1]; !reflect.DeepEqual(extra, anthropic.Message{}) {
+				t.Errorf("got %+v written into the caller's messages", extra)
+			}
+		})
+	}
+}
+
+func TestJSONPrefill(t *testing.T) {
+	tests := []struct {
+		name         string
+		responseType any
+		want         string
+	}{
+		{name: "struct", responseType: anthropicTestPerson{}, want: "{"},
+		{name: "pointer to struct", responseType: &anthropicTestPerson{}, want: "{"},
+		{name: "slice", responseType: []anthropicTestPerson{}, want: "["},
+		{name: "slice of strings", responseType: []string{}, want: "["},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			schema, err := NewSchema(reflect.TypeOf(tt.responseType))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if got := jsonPrefill(schema); got != tt.want {
+				t.Errorf("got %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestAnthropicJSONPrefill(t *testing.T) {
+	requests := []anthropicTestRequest{}
+	client := FromAnthropic(
+		newAnthropicTestClient(t, &requests, anthropicTestContent(anthropic.NewTextMessageContent(`{"name": "Ann"}, {"name": "Bo"}]`))),
+		WithMode(ModeJSON),
+	)
+
+	var people []anthropicTestPerson
+	_, err := client.CreateMessages(context.Background(), anthropic.MessagesRequest{
+		Model:     "test",
+		Messages:  []anthropic.Message{anthropic.NewUserTextMessage("people")},
+		MaxTokens: 100,
+	}, &people)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if want := []anthropicTestPerson{{Name: "Ann"}, {Name: "Bo"}}; !reflect.DeepEqual(people, want) {
+		t.Errorf("got %v, want %v", people, want)
+	}
+
+	messages := requests[0].Messages
+	if want := anthropic.NewAssistantTextMessage("["); !reflect.DeepEqual(messages[len(messages)-1], want) {
+		t.Errorf("got last message %+v, want the prefill %+v", messages[len(messages)-1], want)
+	}
+}
+
+func TestAnthropicToolChoice(t *testing.T) {
+	tests := []struct {
+		name         string
+		responseType any
+		opts         []Options
+		want         *anthropic.ToolChoice
+	}{
+		{
+			name:         "struct",
+			responseType: anthropicTestPerson{},
+			want:         &anthropic.ToolChoice{Type: "tool", Name: "anthropicTestPerson"},
+		},
+		{
+			name:         "slice",
+			responseType: []anthropicTestPerson{},
+			want:         &anthropic.ToolChoice{Type: "any"},
+		},
+		{
+			name:         "auto",
+			responseType: anthropicTestPerson{},
+			opts:         []Options{WithAutoToolChoice()},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			schema, err := NewSchema(reflect.TypeOf(tt.responseType))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			request := &anthropic.MessagesRequest{}
+			FromAnthropic(nil, tt.opts...).addTools(request, schema)
+
+			if !reflect.DeepEqual(request.ToolChoice, tt.want) {
+				t.Errorf("got %+v, want %+v", request.ToolChoice, tt.want)
+			}
+		})
+	}
+}
+
+func TestAnthropicToolCall(t *testing.T) {
+	tests := []struct {
+		name    string
+		content []anthropic.MessageContent
+		want    []anthropicTestPerson
+	}{
+		{
+			name: "one tool_use block",
+			content: []anthropic.MessageContent{
+				anthropic.NewToolUseMessageContent("toolu_1", "anthropicTestPerson", []byte(`{"name": "Ann"}`)),
+			},
+			want: []anthropicTestPerson{{Name: "Ann"}},
+		},
+		{
+			name: "several tool_use blocks after text",
+			content: []anthropic.MessageContent{
+				anthropic.NewTextMessageContent("Calling the tool for each person"),
+				anthropic.NewToolUseMessageContent("toolu_1", "anthropicTestPerson", []byte(`{"name": "Ann"}`)),
+				anthropic.NewToolUseMessageContent("toolu_2", "anthropicTestPerson", []byte(`{"name": "Bo"}`)),
+				anthropic.NewToolUseMessageContent("toolu_3", "anthropicTestPerson", []byte(`{"name": "Cy"}`)),
+			},
+			want: []anthropicTestPerson{{Name: "Ann"}, {Name: "Bo"}, {Name: "Cy"}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client := FromAnthropic(
+				newAnthropicTestClient(t, nil, anthropicTestContent(tt.content...)),
+				WithMode(ModeToolCall),
+			)
+
+			var people []anthropicTestPerson
+			_, err := client.CreateMessages(context.Background(), anthropic.MessagesRequest{
+				Model:     "test",
+				Messages:  []anthropic.Message{anthropic.NewUserTextMessage("people")},
+				MaxTokens: 100,
+			}, &people)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if !reflect.DeepEqual(people, tt.want) {
+				t.Errorf("got %v, want %v", people, tt.want)
+			}
+		})
+	}
+}
+
+func TestAnthropicToolCallWithoutToolUse(t *testing.T) {
+	client := FromAnthropic(
+		newAnthropicTestClient(t, nil, anthropicTestContent(anthropic.NewTextMessageContent("I can't tell"))),
+		WithMode(ModeToolCall),
+		WithMaxRetries(0),
+	)
+
+	var people []anthropicTestPerson
+	_, err := client.CreateMessages(context.Background(), anthropic.MessagesRequest{
+		Model:     "test",
+		Messages:  []anthropic.Message{anthropic.NewUserTextMessage("people")},
+		MaxTokens: 100,
+	}, &people)
+
+	var maxRetriesErr *MaxRetriesExceededError
+	if !errors.As(err, &maxRetriesErr) {
+		t.Fatalf("got error %v, want a *MaxRetriesExceededError", err)
+	}
+	if attempt := maxRetriesErr.Attempts[0]; attempt.Text != "I can't tell" || !errors.Is(attempt.Err, errNoToolCalls) {
+		t.Errorf("got attempt %+v, want the text and errNoToolCalls", attempt)
+	}
+}
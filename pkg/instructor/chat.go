@@ -4,15 +4,15 @@ import (
 	"context"
 	"encoding/json"
 	"errors"
+	"fmt"
 	"reflect"
-
-	"github.com/go-playground/validator/v10"
+	"strings"
 )
 
 type UsageSum struct {
-	InputTokens  int
-	OutputTokens int
-	TotalTokens  int
+	InputTokens  int `json:"input_tokens"`
+	OutputTokens int `json:"output_tokens"`
+	TotalTokens  int `json:"total_tokens"`
 }
 
 func chatHandler(i Instructor, ctx context.Context, request interface{}, response any) (interface{}, error) {
@@ -26,47 +26,150 @@ func chatHandler(i Instructor, ctx context.Context, request interface{}, respons
 		return nil, err
 	}
 
+	// natural-language rules from struct tags and WithLLMRule
+	llmRules := append(llmRulesFromTags(t), i.LLMRules(t)...)
+
 	// keep a running total of usage
 	usage := &UsageSum{}
 
+	// every rejected attempt, reported once retries run out
+	attempts := []Attempt{}
+
+	// model of the current attempt under model escalation
+	model := ""
+
+	reject := func(text string, resp interface{}, err error) {
+		attemptUsage := i.countUsageFromResponse(resp, &UsageSum{})
+
+		usage.InputTokens += attemptUsage.InputTokens
+		usage.OutputTokens += attemptUsage.OutputTokens
+		usage.TotalTokens += attemptUsage.TotalTokens
+
+		attempts = append(attempts, Attempt{Text: text, Err: err, Usage: *attemptUsage, Model: model})
+
+		// send back the generated JSON and the error for the model to fix
+		request = i.addReaskMessages(request, resp, text, err)
+	}
+
 	for attempt := 0; attempt <= i.MaxRetries(); attempt++ {
 
-		text, resp, err := i.chat(ctx, request, schema)
+		if next := i.ModelEscalation().modelFor(attempt); next != model {
+			model = next
+			request = i.setModel(request, model)
+			i.reportModel(model)
+			if onEscalate := i.ModelEscalation().OnEscalate; onEscalate != nil {
+				onEscalate(model)
+			}
+		}
+
+		var (
+			text string
+			resp interface{}
+		)
+		err := retryWithPolicy(ctx, i.RetryPolicy(), func(ctx context.Context) error {
+			var err error
+			text, resp, err = i.chat(ctx, request, schema)
+			return err
+		})
+		var decodeErr *DecodeError
+		if errors.As(err, &decodeErr) {
+			// ex: no tool calls, reask like unusable JSON
+			reject(text, resp, err)
+			continue
+		}
 		if err != nil {
-			// no retry on non-marshalling/validation errors
-			return i.emptyResponseWithResponseUsage(resp), err
+			// provider errors left after the retry policy are not retried
+			var providerErr *ProviderError
+			if errors.As(err, &providerErr) {
+				providerErr.Attempts = attempts
+			}
+			return i.emptyResponseWithUsageSum(i.countUsageFromResponse(resp, usage)), err
 		}
 
-		text = extractJSON(&text)
+		var jsonText string
+		if i.Mode() == ModeMarkdownJSON {
+			jsonText = extractMarkdownJSON(&text)
+		} else {
+			jsonText = extractJSON(&text)
+		}
 
-		err = json.Unmarshal([]byte(text), &response)
-		if err != nil {
-			// TODO:
-			// add more sophisticated retry logic (send back json and parse error for model to fix).
-			//
-			// Currently, its just recalling with no new information
-			// or attempt to fix the error with the last generated JSON
+		// elements dropped under partial acceptance
+		var dropped []DroppedElement
 
-			i.countUsageFromResponse(resp, usage)
+		if partial := i.PartialAcceptance(); partial != nil && indirectType(t).Kind() == reflect.Slice {
+			dropped, err = decodePartial(i, jsonText, response)
+			if err == nil && len(dropped) > partial.MaxDropped {
+				err = &ValidationError{Err: droppedErrors(dropped)}
+			}
+		} else {
+			err = decodeAndValidate(i, jsonText, response)
+		}
+		if err != nil {
+			reject(text, resp, err)
 			continue
 		}
 
-		if i.Validate() {
-			validate = validator.New()
-			// Validate the response structure against the defined model using the validator
-			err = validate.Struct(response)
-
-			if err != nil {
-				// TODO:
-				// add more sophisticated retry logic (send back validator error and parse error for model to fix).
+		// Check natural-language rules with secondary calls to the model
+		failures, err := validateWithLLM(i, ctx, request, response, llmRules, usage)
+		if err != nil {
+			return i.emptyResponseWithUsageSum(i.countUsageFromResponse(resp, usage)), err
+		}
+		if failures != nil {
+			reject(text, resp, &ValidationError{Err: failures})
+			continue
+		}
 
-				i.countUsageFromResponse(resp, usage)
-				continue
-			}
+		if len(dropped) > 0 && i.PartialAcceptance().OnDropped != nil {
+			i.PartialAcceptance().OnDropped(dropped)
 		}
 
 		return i.addUsageSumToResponse(resp, usage)
 	}
 
-	return i.emptyResponseWithUsageSum(usage), errors.New("hit max retry attempts")
+	return i.emptyResponseWithUsageSum(usage), &MaxRetriesExceededError{Attempts: attempts}
+}
+
+// Decodes the JSON into the response and runs all validations, returning
+// a *DecodeError or *ValidationError
+func decodeAndValidate(i Instructor, jsonText string, response any) error {
+	err := json.Unmarshal([]byte(jsonText), &response)
+	if err != nil {
+		return &DecodeError{Text: jsonText, Err: err}
+	}
+
+	if !i.Validate() {
+		return nil
+	}
+
+	// Validate the response structure against the defined model using the validator
+	err = validateStruct(i.Validator(), response)
+	if err != nil {
+		return &ValidationError{Err: err}
+	}
+
+	// Run Validate() of response types implementing Validatable
+	err = validateResponse(response)
+	if err != nil {
+		return &ValidationError{Err: err}
+	}
+
+	return nil
+}
+
+// Builds the message sent back to the model after a failed attempt
+func reaskPrompt(err error) string {
+	reason := err.Error()
+
+	var validationErr *ValidationError
+	if errors.As(err, &validationErr) {
+		reason = "- " + strings.Join(validationErr.Messages(), "\n- ")
+	}
+
+	return fmt.Sprintf(`
+Your previous response could not be used because of the following errors:
+
+%s
+
+Please fix the errors and respond again with a valid JSON instance of the schema.
+`, reason)
 }
@@ -3,10 +3,13 @@ package instructor
 import (
 	"context"
 	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
 	"reflect"
-	"strings"
 
 	"github.com/go-playground/validator/v10"
+	"github.com/invopop/jsonschema"
 )
 
 type StreamWrapper[T any] struct {
@@ -15,10 +18,49 @@ type StreamWrapper[T any] struct {
 
 const WRAPPER_END = `"items": [`
 
-func chatStreamHandler(i Instructor, ctx context.Context, request interface{}, response any) (<-chan interface{}, error) {
+func chatStreamHandler(i Instructor, ctx context.Context, request interface{}, response any) (*Stream[any], error) {
 
 	responseType := reflect.TypeOf(response)
 
+	// cancelled by Stream.Close, or once the stream ended
+	ctx, cancel := context.WithCancel(ctx)
+
+	ch, err := startStream(i, ctx, request, responseType)
+	if err != nil {
+		cancel()
+		return nil, err
+	}
+
+	return parseStream(ctx, cancel, ch, streamValidator(i), responseType), nil
+}
+
+// Requests the provider stream of a StreamWrapper of the response type
+func startStream(i Instructor, ctx context.Context, request interface{}, responseType reflect.Type) (<-chan streamChunk, error) {
+
+	schema, err := newStreamSchema(responseType)
+	if err != nil {
+		return nil, err
+	}
+
+	var ch <-chan streamChunk
+	err = retryWithPolicy(ctx, i.RetryPolicy(), func(ctx context.Context) error {
+		ch, err = i.chatStream(ctx, request, schema)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if i.Mode() == ModeMarkdownJSON {
+		ch = markdownJSONStream(ctx, ch)
+	}
+
+	return ch, nil
+}
+
+// Schema of a StreamWrapper of the response type
+func newStreamSchema(responseType reflect.Type) (*Schema, error) {
+
 	streamWrapperType := reflect.StructOf([]reflect.StructField{
 		{
 			Name:      "Items",
@@ -33,113 +75,213 @@ func chatStreamHandler(i Instructor, ctx context.Context, request interface{}, r
 		return nil, err
 	}
 
-	ch, err := i.chatStream(ctx, request, schema)
-	if err != nil {
-		return nil, err
+	if indirectType(responseType).Kind() != reflect.Struct {
+		// tool arguments are objects, so strings, numbers or arrays are
+		// generated as the items of a single StreamWrapper tool call
+		schema.Functions = []FunctionDefinition{streamWrapperFunction(schema)}
+		schema.streamWrapper = true
 	}
 
-	shouldValidate := i.Validate()
-	if shouldValidate {
-		validate = validator.New()
-	}
+	return schema, nil
+}
 
-	parsedChan := parseStream(ctx, ch, shouldValidate, responseType)
+func streamWrapperFunction(schema *Schema) FunctionDefinition {
+	return FunctionDefinition{
+		Name:        "items",
+		Description: "Items of the response",
+		Parameters: &jsonschema.Schema{
+			Type:        "object",
+			Properties:  schema.Properties,
+			Required:    schema.Required,
+			Definitions: schema.Definitions,
+		},
+	}
+}
 
-	return parsedChan, nil
+// nil disables validation
+func streamValidator(i Instructor) *validator.Validate {
+	if !i.Validate() {
+		return nil
+	}
+	return i.Validator()
 }
 
-func parseStream(ctx context.Context, ch <-chan string, shouldValidate bool, responseType reflect.Type) <-chan interface{} {
+func parseStream(ctx context.Context, cancel context.CancelFunc, ch <-chan streamChunk, validate *validator.Validate, responseType reflect.Type) *Stream[any] {
 
-	parsedChan := make(chan any)
+	stream, items := newStream[any](cancel)
 
 	go func() {
-		defer close(parsedChan)
-
-		buffer := new(strings.Builder)
-		inArray := false
-
-		for {
-			select {
-			case <-ctx.Done():
-				return
-			case text, ok := <-ch:
-				if !ok {
-					// Stream closed
-					processRemainingBuffer(buffer, parsedChan, shouldValidate, responseType)
-					return
-				}
+		elements := newStreamElements()
 
-				buffer.WriteString(text)
+		err := readStream(ctx, ch, stream, elements, func(ended bool) bool {
+			for {
+				element, index, found := elements.next()
+				if !found {
+					return true
+				}
 
-				// Eat all input until elements stream starts
-				if !inArray {
-					inArray = startArray(buffer)
+				instance, dropped := decodeStreamElement(element, index, validate, responseType)
+				if dropped != nil {
+					stream.drop(*dropped)
+					continue
 				}
 
-				processBuffer(buffer, parsedChan, shouldValidate, responseType)
+				if !stream.send(ctx, items, instance) {
+					return false
+				}
 			}
-		}
+		})
+
+		stream.finish(items, err)
 	}()
 
-	return parsedChan
+	return stream
 }
 
-func startArray(buffer *strings.Builder) bool {
+// Feeds the chunks of the provider stream to elements, adding their usage to
+// the stream, and calls update after every chunk and once more when the
+// stream is closed (ended set). update returns false once ctx is done.
+// Returns the error ending the stream, nil when it was read to the end.
+func readStream[T any](ctx context.Context, ch <-chan streamChunk, stream *Stream[T], elements *streamElements, update func(ended bool) bool) error {
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case chunk, ok := <-ch:
+			// a failed chunk may still report the tokens used
+			if chunk.Usage != nil {
+				stream.addUsage(*chunk.Usage)
+			}
+			if ok && chunk.Err != nil {
+				return chunk.Err
+			}
+			if !ok && ctx.Err() != nil {
+				// the provider stream was closed because ctx is done
+				return ctx.Err()
+			}
+
+			if ok {
+				elements.write(chunk.Text)
+			} else {
+				elements.end()
+			}
 
-	data := buffer.String()
+			if !update(!ok) {
+				return ctx.Err()
+			}
 
-	idx := strings.Index(data, WRAPPER_END)
-	if idx == -1 {
-		return false
+			if !ok {
+				// Stream closed
+				return elements.close(stream.drop)
+			}
+		}
 	}
+}
 
-	trimmed := strings.TrimSpace(data[idx+len(WRAPPER_END):])
-	buffer.Reset()
-	buffer.WriteString(trimmed)
+// streamElements splits the streamed StreamWrapper into the elements of its
+// items array
+type streamElements struct {
+	scanner *jsonElementScanner
+	// position of the next element in the items array
+	index int
+}
 
-	return true
+func newStreamElements() *streamElements {
+	return &streamElements{scanner: newJSONElementScanner()}
 }
 
-func processBuffer(buffer *strings.Builder, parsedChan chan<- interface{}, shouldValidate bool, responseType reflect.Type) {
+func (s *streamElements) write(text string) {
+	s.scanner.write(text)
+}
 
-	data := buffer.String()
+// end marks the end of the model output
+func (s *streamElements) end() {
+	s.scanner.end()
+}
 
-	data, remaining := getFirstFullJSONElement(&data)
+// Returns the next complete element of the items array, if any
+func (s *streamElements) next() (element string, index int, found bool) {
+	element, found = s.scanner.next()
+	if !found {
+		return "", 0, false
+	}
 
-	decoder := json.NewDecoder(strings.NewReader(data))
+	index = s.index
+	s.index++
 
-	for decoder.More() {
-		instance := reflect.New(responseType).Interface()
-		err := decoder.Decode(instance)
-		if err != nil {
-			break
-		}
+	return element, index, true
+}
 
-		if shouldValidate {
-			// Validate the instance
-			err = validate.Struct(instance)
-			if err != nil {
-				break
-			}
-		}
+// Text of the element being generated, empty between elements
+func (s *streamElements) pending() string {
+	return s.scanner.pending()
+}
 
-		parsedChan <- instance
+// Changes when values of the element being generated are completed, or
+// objects and arrays opened or closed
+func (s *streamElements) events() int {
+	return s.scanner.events
+}
 
-		buffer.Reset()
-		buffer.WriteString(remaining)
+// Checks the end of the model output once the stream is closed, reporting an
+// unfinished last element as dropped
+func (s *streamElements) close(drop func(DroppedElement)) error {
+	if !s.scanner.started() {
+		return &DecodeError{
+			Text: string(s.scanner.buf),
+			Err:  errors.New("stream ended before the items array started"),
+		}
 	}
-}
 
-func processRemainingBuffer(buffer *strings.Builder, parsedChan chan<- interface{}, shouldValidate bool, responseType reflect.Type) {
+	if data := s.pending(); data != "" {
+		drop(DroppedElement{
+			Index: s.index,
+			Text:  data,
+			Err: &DecodeError{Text: data, Err: &FieldError{
+				Path:    indexPath("", s.index),
+				Message: fmt.Sprintf("%s was not complete when the stream ended", indexPath("", s.index)),
+				Err:     io.ErrUnexpectedEOF,
+			}},
+		})
+	}
 
-	data := buffer.String()
+	return nil
+}
 
-	data = extractJSON(&data)
+// Decodes and validates an element of the items array, returning why it was
+// dropped on failure
+func decodeStreamElement(element string, index int, validate *validator.Validate, responseType reflect.Type) (any, *DroppedElement) {
+
+	path := indexPath("", index)
+
+	instance := reflect.New(responseType).Interface()
+	if err := json.Unmarshal([]byte(element), instance); err != nil {
+		return nil, &DroppedElement{
+			Index: index,
+			Text:  element,
+			Err: &DecodeError{Text: element, Err: &FieldError{
+				Path:    path,
+				Message: fmt.Sprintf("%s could not be decoded: %v", path, err),
+				Err:     err,
+			}},
+		}
+	}
 
-	if idx := strings.LastIndex(data, "]"); idx != -1 {
-		data = data[:idx]
+	var errs []error
+	if validate != nil {
+		// Validate the instance
+		errs = append(errs, validateStructAt(validate, reflect.ValueOf(instance), path))
+		// Run Validate() of response types implementing Validatable
+		errs = append(errs, validateValue(reflect.ValueOf(instance), path))
 	}
 
-	processBuffer(buffer, parsedChan, shouldValidate, responseType)
+	if err := errors.Join(errs...); err != nil {
+		return nil, &DroppedElement{
+			Index: index,
+			Text:  element,
+			Err:   &ValidationError{Err: err},
+		}
+	}
 
+	return instance, nil
 }
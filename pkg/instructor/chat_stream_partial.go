@@ -0,0 +1,316 @@
+package instructor
+
+import (
+	"context"
+	"encoding/json"
+	"maps"
+	"reflect"
+	"strings"
+
+	"github.com/go-playground/validator/v10"
+)
+
+// PartialResponse is a progressively filled copy of a streamed response,
+// emitted every time more of the response has been generated
+type PartialResponse struct {
+	// Position of the response in the stream, responses are filled in order
+	Index int `json:"index"`
+	// Pointer to a new instance of the response type, values still being
+	// generated are left at their zero values
+	Value any `json:"value"`
+	// JSON paths of the values that are fully generated, ex: "tickets[0].title"
+	Complete map[string]bool `json:"complete"`
+	// Set on the last copy of a response, once it is complete and validated
+	Done bool `json:"done"`
+}
+
+// IsComplete reports whether the value at the JSON path is fully generated,
+// the empty path addresses the response itself
+func (p *PartialResponse) IsComplete(path string) bool {
+	return p.Done || p.Complete[path]
+}
+
+func chatPartialStreamHandler(i Instructor, ctx context.Context, request interface{}, response any) (*Stream[*PartialResponse], error) {
+
+	responseType := reflect.TypeOf(response)
+
+	// cancelled by Stream.Close, or once the stream ended
+	ctx, cancel := context.WithCancel(ctx)
+
+	ch, err := startStream(i, ctx, request, responseType)
+	if err != nil {
+		cancel()
+		return nil, err
+	}
+
+	return parsePartialStream(ctx, cancel, ch, streamValidator(i), responseType), nil
+}
+
+func parsePartialStream(ctx context.Context, cancel context.CancelFunc, ch <-chan streamChunk, validate *validator.Validate, responseType reflect.Type) *Stream[*PartialResponse] {
+
+	stream, partials := newStream[*PartialResponse](cancel)
+
+	go func() {
+		elements := newStreamElements()
+		// parser of the response in progress, fed as it is generated
+		parser := newPartialJSONParser()
+		// end of the usable beginning of the last emitted copy, to skip
+		// unchanged copies
+		lastUsable := 0
+		// the response in progress is only parsed again once its content changed
+		lastEvents := 0
+
+		err := readStream(ctx, ch, stream, elements, func(ended bool) bool {
+			// Emit the completed responses
+			for {
+				element, index, found := elements.next()
+				if !found {
+					break
+				}
+
+				parser.parse(element)
+				complete := parser.complete
+				parser = newPartialJSONParser()
+				lastUsable = 0
+
+				instance, dropped := decodeStreamElement(element, index, validate, responseType)
+				if dropped != nil {
+					stream.drop(*dropped)
+					continue
+				}
+
+				partial := &PartialResponse{
+					Index:    index,
+					Value:    instance,
+					Complete: complete,
+					Done:     true,
+				}
+				if !stream.send(ctx, partials, partial) {
+					return false
+				}
+			}
+
+			if ended || elements.events() == lastEvents {
+				return true
+			}
+			lastEvents = elements.events()
+
+			// Emit the response in progress
+			parser.parse(elements.pending())
+			if parser.usable == lastUsable {
+				return true
+			}
+			partial := decodePartialResponse(parser, responseType, elements.index)
+			if partial == nil {
+				return true
+			}
+			lastUsable = parser.usable
+
+			return stream.send(ctx, partials, partial)
+		})
+
+		stream.finish(partials, err)
+	}()
+
+	return stream
+}
+
+// Decodes the beginning of the response parsed so far
+func decodePartialResponse(parser *partialJSONParser, responseType reflect.Type, index int) *PartialResponse {
+
+	data := parser.json()
+	if data == "" {
+		return nil
+	}
+
+	instance := reflect.New(responseType).Interface()
+	if err := json.Unmarshal([]byte(data), instance); err != nil {
+		// generated values don't fit the response type (yet)
+		return nil
+	}
+
+	return &PartialResponse{
+		Index:    index,
+		Value:    instance,
+		Complete: maps.Clone(parser.complete),
+	}
+}
+
+// partialJSONParser parses the beginning of a JSON value as it is generated,
+// resuming where the previous call stopped. It keeps the end of the longest
+// beginning that is usable once its open objects and arrays are closed:
+// strings, numbers and literals still being generated are left out.
+type partialJSONParser struct {
+	data string
+	pos  int
+	// containers open at pos, innermost last
+	stack []partialJSONContainer
+	// JSON paths of the values fully generated, ex: "tickets[0].title"
+	complete map[string]bool
+
+	inString  bool
+	escaped   bool
+	inLiteral bool
+	// start of the string or literal at pos
+	tokenStart int
+	// malformed JSON, the usable beginning no longer grows
+	malformed bool
+
+	// end of the usable beginning and the text closing its open containers
+	usable  int
+	closing string
+}
+
+type partialJSONContainer struct {
+	object bool
+	path   string
+	// object: key of the current field, set once the key is complete
+	key    string
+	hasKey bool
+	// array: index of the current element
+	index int
+}
+
+func newPartialJSONParser() *partialJSONParser {
+	return &partialJSONParser{complete: map[string]bool{}}
+}
+
+// Parses the data added since the previous call, data must start with the
+// data of the previous call
+func (p *partialJSONParser) parse(data string) {
+	p.data = data
+
+	for p.pos < len(p.data) && !p.malformed {
+		c := p.data[p.pos]
+
+		if p.inString {
+			switch {
+			case p.escaped:
+				p.escaped = false
+			case c == '\\':
+				p.escaped = true
+			case c == '"':
+				p.inString = false
+				p.endString()
+			}
+			p.pos++
+			continue
+		}
+
+		if p.inLiteral {
+			if !strings.ContainsRune(",:{}[]\" \t\r\n", rune(c)) {
+				p.pos++
+				continue
+			}
+			// a literal is only done once followed by another character, it
+			// may still be generated at the end of the data
+			p.inLiteral = false
+			p.endLiteral()
+			if p.malformed {
+				return
+			}
+		}
+
+		switch c {
+		case ' ', '\t', '\r', '\n', ':':
+		case ',':
+			if top := p.top(); top != nil {
+				top.hasKey = false
+				if !top.object {
+					top.index++
+				}
+			}
+		case '{', '[':
+			p.stack = append(p.stack, partialJSONContainer{object: c == '{', path: p.valuePath()})
+			p.setUsable(p.pos + 1)
+		case '}', ']':
+			if len(p.stack) == 0 {
+				p.malformed = true
+				return
+			}
+			container := p.stack[len(p.stack)-1]
+			p.stack = p.stack[:len(p.stack)-1]
+			p.complete[container.path] = true
+			p.setUsable(p.pos + 1)
+		case '"':
+			p.inString = true
+			p.tokenStart = p.pos
+		default:
+			p.inLiteral = true
+			p.tokenStart = p.pos
+		}
+		p.pos++
+	}
+}
+
+// Returns the usable beginning of the data with its open objects and arrays
+// closed, empty when nothing is usable yet
+func (p *partialJSONParser) json() string {
+	if p.usable == 0 {
+		return ""
+	}
+	return p.data[:p.usable] + p.closing
+}
+
+func (p *partialJSONParser) top() *partialJSONContainer {
+	if len(p.stack) == 0 {
+		return nil
+	}
+	return &p.stack[len(p.stack)-1]
+}
+
+// Path of the value starting at pos
+func (p *partialJSONParser) valuePath() string {
+	top := p.top()
+	switch {
+	case top == nil:
+		return ""
+	case top.object:
+		return fieldPath(top.path, top.key)
+	default:
+		return indexPath(top.path, top.index)
+	}
+}
+
+func (p *partialJSONParser) endString() {
+	token := p.data[p.tokenStart : p.pos+1]
+
+	if top := p.top(); top != nil && top.object && !top.hasKey {
+		if err := json.Unmarshal([]byte(token), &top.key); err != nil {
+			p.malformed = true
+			return
+		}
+		top.hasKey = true
+		return
+	}
+
+	if !json.Valid([]byte(token)) {
+		p.malformed = true
+		return
+	}
+	p.complete[p.valuePath()] = true
+	p.setUsable(p.pos + 1)
+}
+
+func (p *partialJSONParser) endLiteral() {
+	if !json.Valid([]byte(p.data[p.tokenStart:p.pos])) {
+		p.malformed = true
+		return
+	}
+	p.complete[p.valuePath()] = true
+	p.setUsable(p.pos)
+}
+
+func (p *partialJSONParser) setUsable(end int) {
+	closing := make([]byte, 0, len(p.stack))
+	for idx := len(p.stack) - 1; idx >= 0; idx-- {
+		if p.stack[idx].object {
+			closing = append(closing, '}')
+		} else {
+			closing = append(closing, ']')
+		}
+	}
+
+	p.usable = end
+	p.closing = string(closing)
+}
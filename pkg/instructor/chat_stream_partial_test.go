@@ -0,0 +1,87 @@
+package instructor
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestPartialJSONParser(t *testing.T) {
+	tests := []struct {
+		name     string
+		data     string
+		want     string
+		complete []string
+	}{
+		{
+			name: "nothing usable",
+			data: `{"a`,
+			want: `{}`,
+		},
+		{
+			name:     "string being generated",
+			data:     `{"a": "x", "b": "y`,
+			want:     `{"a": "x"}`,
+			complete: []string{"a"},
+		},
+		{
+			name:     "number being generated",
+			data:     `{"a": [1, 2`,
+			want:     `{"a": [1]}`,
+			complete: []string{"a[0]"},
+		},
+		{
+			name:     "nested objects",
+			data:     `{"a": {"b": true}, "c": [{"d": null}, {"e": `,
+			want:     `{"a": {"b": true}, "c": [{"d": null}, {}]}`,
+			complete: []string{"a", "a.b", "c[0]", "c[0].d"},
+		},
+		{
+			name:     "escaped quote",
+			data:     `{"a": "x \"y\"", "b": "\"`,
+			want:     `{"a": "x \"y\""}`,
+			complete: []string{"a"},
+		},
+		{
+			name:     "complete",
+			data:     `{"a": [1, "x"]}`,
+			want:     `{"a": [1, "x"]}`,
+			complete: []string{"", "a", "a[0]", "a[1]"},
+		},
+		{
+			name:     "malformed",
+			data:     `{"a": 1, "b": tru, "c": 2}`,
+			want:     `{"a": 1}`,
+			complete: []string{"a"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			want := map[string]bool{}
+			for _, path := range tt.complete {
+				want[path] = true
+			}
+
+			p := newPartialJSONParser()
+			p.parse(tt.data)
+			if got := p.json(); got != tt.want {
+				t.Errorf("got %q, want %q", got, tt.want)
+			}
+			if !reflect.DeepEqual(p.complete, want) {
+				t.Errorf("got complete %v, want %v", p.complete, want)
+			}
+
+			// resuming one byte at a time
+			p = newPartialJSONParser()
+			for i := 0; i < len(tt.data); i++ {
+				p.parse(tt.data[:i+1])
+			}
+			if got := p.json(); got != tt.want {
+				t.Errorf("byte by byte: got %q, want %q", got, tt.want)
+			}
+			if !reflect.DeepEqual(p.complete, want) {
+				t.Errorf("byte by byte: got complete %v, want %v", p.complete, want)
+			}
+		})
+	}
+}
@@ -0,0 +1,69 @@
+package instructor
+
+import (
+	"errors"
+	"testing"
+)
+
+type chatTestTotal struct {
+	Total int `json:"total"`
+}
+
+func (t *chatTestTotal) Validate() error {
+	if t.Total < 0 {
+		return errors.New("total must not be negative")
+	}
+	return nil
+}
+
+func TestDecodeAndValidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		opts    []Options
+		text    string
+		wantErr error
+	}{
+		{
+			name: "valid",
+			opts: []Options{WithValidation()},
+			text: `{"total": 1}`,
+		},
+		{
+			name:    "Validate() fails",
+			opts:    []Options{WithValidation()},
+			text:    `{"total": -1}`,
+			wantErr: &ValidationError{},
+		},
+		{
+			name: "Validate() not run without WithValidation",
+			text: `{"total": -1}`,
+		},
+		{
+			name:    "invalid JSON",
+			text:    `{"total": "x"}`,
+			wantErr: &DecodeError{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client := FromOpenAI(nil, tt.opts...)
+
+			err := decodeAndValidate(client, tt.text, &chatTestTotal{})
+			switch want := tt.wantErr.(type) {
+			case nil:
+				if err != nil {
+					t.Errorf("got error %v, want none", err)
+				}
+			case *ValidationError:
+				if !errors.As(err, &want) {
+					t.Errorf("got error %v, want a *ValidationError", err)
+				}
+			case *DecodeError:
+				if !errors.As(err, &want) {
+					t.Errorf("got error %v, want a *DecodeError", err)
+				}
+			}
+		})
+	}
+}
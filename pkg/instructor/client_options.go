@@ -0,0 +1,107 @@
+package instructor
+
+import (
+	"reflect"
+
+	"github.com/go-playground/validator/v10"
+)
+
+// clientOptions are the options of a client resolved over the defaults,
+// embedded by the client of every provider
+type clientOptions struct {
+	provider    Provider
+	mode        Mode
+	maxRetries  int
+	retryPolicy RetryPolicy
+	partial     *PartialAcceptance
+	escalation  *ModelEscalation
+	validate    bool
+	validator   *validator.Validate
+	llmRules    map[reflect.Type][]LLMRule
+
+	forceToolCall bool
+	modelReport   *string
+
+	// options the client was created with, per-call options are merged over them
+	options Options
+}
+
+func newClientOptions(provider Provider, opts ...Options) clientOptions {
+
+	options := mergeOptions(opts...)
+
+	// created once, so per-call options keep using the client's validator
+	options.validator = options.validatorOrNew()
+
+	return resolveOptions(provider, options)
+}
+
+func resolveOptions(provider Provider, options Options) clientOptions {
+	return clientOptions{
+		provider:    provider,
+		mode:        *options.Mode,
+		maxRetries:  *options.MaxRetries,
+		retryPolicy: *options.RetryPolicy,
+		partial:     options.PartialAcceptance,
+		escalation:  options.ModelEscalation,
+		validate:    *options.validate,
+		validator:   options.validatorOrNew(),
+		llmRules:    options.llmRules,
+
+		forceToolCall: *options.forceToolCall,
+		modelReport:   options.modelReport,
+
+		options: options,
+	}
+}
+
+// Merges per-call options over the client's options, returning a copy so
+// concurrent calls with different options never share state
+func (o clientOptions) with(opts ...Options) clientOptions {
+	if len(opts) == 0 {
+		return o
+	}
+	return resolveOptions(o.provider, mergeOptionsOver(o.options, opts...))
+}
+
+// Options running the secondary calls of LLM validation, see Options.forValidation
+func (o clientOptions) forValidation() clientOptions {
+	return resolveOptions(o.provider, o.options.forValidation())
+}
+
+func (o clientOptions) Provider() Provider {
+	return o.provider
+}
+func (o clientOptions) Mode() Mode {
+	return o.mode
+}
+func (o clientOptions) MaxRetries() int {
+	return o.maxRetries
+}
+func (o clientOptions) RetryPolicy() RetryPolicy {
+	return o.retryPolicy
+}
+func (o clientOptions) PartialAcceptance() *PartialAcceptance {
+	return o.partial
+}
+func (o clientOptions) ModelEscalation() *ModelEscalation {
+	return o.escalation
+}
+func (o clientOptions) Validate() bool {
+	return o.validate
+}
+func (o clientOptions) Validator() *validator.Validate {
+	return o.validator
+}
+func (o clientOptions) LLMRules(responseType reflect.Type) []LLMRule {
+	return o.llmRules[indirectType(responseType)]
+}
+func (o clientOptions) ForceToolCall() bool {
+	return o.forceToolCall
+}
+
+func (o clientOptions) reportModel(model string) {
+	if o.modelReport != nil {
+		*o.modelReport = model
+	}
+}
@@ -2,20 +2,22 @@ package instructor
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
+	"strings"
 
 	cohere "github.com/cohere-ai/cohere-go/v2"
-	option "github.com/cohere-ai/cohere-go/v2/option"
+	"github.com/invopop/jsonschema"
 )
 
 func (i *InstructorCohere) Chat(
 	ctx context.Context,
 	request *cohere.ChatRequest,
 	response any,
-	opts ...option.RequestOption,
+	opts ...Options,
 ) (*cohere.NonStreamedChatResponse, error) {
 
-	resp, err := chatHandler(i, ctx, request, response)
+	resp, err := chatHandler(i.withOptions(opts...), ctx, request, response)
 	if err != nil {
 		if resp == nil {
 			return &cohere.NonStreamedChatResponse{}, err
@@ -33,31 +35,70 @@ func (i *InstructorCohere) chat(ctx context.Context, request interface{}, schema
 		return "", nil, fmt.Errorf("invalid request type for %s client", i.Provider())
 	}
 
+	// copy so that prompts added for this attempt do not leak into the caller's request
+	req = toPtr(*req)
+
 	switch i.Mode() {
 	case ModeToolCall:
 		return i.chatToolCall(ctx, req, schema)
 	case ModeJSON:
 		return i.chatJSON(ctx, req, schema)
+	case ModeMarkdownJSON:
+		return i.chatMarkdownJSON(ctx, req, schema)
 	default:
-		return "", nil, fmt.Errorf("mode '%s' is not supported for %s", i.Mode(), i.Provider())
+		return "", nil, &UnsupportedModeError{Provider: i.Provider(), Mode: i.Mode()}
 	}
 }
 
 func (i *InstructorCohere) chatToolCall(ctx context.Context, request *cohere.ChatRequest, schema *Schema) (string, *cohere.NonStreamedChatResponse, error) {
 
-	request.Tools = []*cohere.Tool{createCohereTools(schema)}
+	request.Tools = createCohereTools(schema)
+	if i.ForceToolCall() {
+		// the Cohere chat API has no tool_choice, so the model is instructed instead
+		request.Preamble = concatCoherePreamble(request.Preamble, toolChoicePrompt(schema))
+	}
 
 	resp, err := i.Client.Chat(ctx, request)
 	if err != nil {
-		return "", nil, err
+		return "", nil, newProviderError(i.Provider(), err)
 	}
 
-	_ = resp
+	toolCalls := []*cohere.ToolCall{}
+	for _, toolCall := range resp.ToolCalls {
+		if toolCall != nil {
+			toolCalls = append(toolCalls, toolCall)
+		}
+	}
+
+	numTools := len(toolCalls)
+
+	if numTools < 1 {
+		return resp.Text, resp, &DecodeError{Text: resp.Text, Err: errNoToolCalls}
+	}
 
-	// TODO: implement
+	// list responses are an array even when made of a single tool call
+	if numTools == 1 && schema.Type != "array" {
+		parameters, err := json.Marshal(toolCalls[0].Parameters)
+		if err != nil {
+			return "", nilCohereRespWithUsage(resp), err
+		}
+		return string(parameters), resp, nil
+	}
+
+	// numTools > 1, or a list response
+
+	jsonArray := make([]map[string]interface{}, len(toolCalls))
+
+	for i, toolCall := range toolCalls {
+		jsonArray[i] = toolCall.Parameters
+	}
 
-	panic("tool call not implemented Cohere")
+	resultJSON, err := json.Marshal(jsonArray)
+	if err != nil {
+		return "", nilCohereRespWithUsage(resp), err
+	}
 
+	return string(resultJSON), resp, nil
 }
 
 func (i *InstructorCohere) chatJSON(ctx context.Context, request *cohere.ChatRequest, schema *Schema) (string, *cohere.NonStreamedChatResponse, error) {
@@ -66,12 +107,31 @@ func (i *InstructorCohere) chatJSON(ctx context.Context, request *cohere.ChatReq
 
 	resp, err := i.Client.Chat(ctx, request)
 	if err != nil {
-		return "", nil, err
+		return "", nil, newProviderError(i.Provider(), err)
 	}
 
 	return resp.Text, resp, nil
 }
 
+func (i *InstructorCohere) chatMarkdownJSON(ctx context.Context, request *cohere.ChatRequest, schema *Schema) (string, *cohere.NonStreamedChatResponse, error) {
+
+	request.Preamble = concatCoherePreamble(request.Preamble, markdownJSONPrompt(schema))
+
+	resp, err := i.Client.Chat(ctx, request)
+	if err != nil {
+		return "", nil, newProviderError(i.Provider(), err)
+	}
+
+	return resp.Text, resp, nil
+}
+
+func concatCoherePreamble(preamble *string, prompt string) *string {
+	if preamble == nil {
+		return &prompt
+	}
+	return toPtr(*preamble + "\n" + prompt)
+}
+
 func (i *InstructorCohere) addOrConcatJSONSystemPrompt(request *cohere.ChatRequest, schema *Schema) {
 
 	schemaPrompt := fmt.Sprintf("```json!Please respond with JSON in the following JSON schema - make sure to return an instance of the JSON, not the schema itself: %s ", schema.String)
@@ -83,6 +143,64 @@ func (i *InstructorCohere) addOrConcatJSONSystemPrompt(request *cohere.ChatReque
 	}
 }
 
+func (i *InstructorCohere) setModel(request interface{}, model string) interface{} {
+	req, ok := request.(*cohere.ChatRequest)
+	if !ok {
+		return request
+	}
+	// copy so the caller's request keeps its model
+	req = toPtr(*req)
+	req.Model = toPtr(model)
+	return req
+}
+
+func (i *InstructorCohere) validationRequest(request interface{}, prompt string) interface{} {
+	var model *string
+	if req, ok := request.(*cohere.ChatRequest); ok {
+		model = req.Model
+	}
+
+	return &cohere.ChatRequest{
+		Model:   model,
+		Message: prompt,
+	}
+}
+
+func (i *InstructorCohere) addReaskMessages(request interface{}, response interface{}, text string, err error) interface{} {
+	req, ok := request.(*cohere.ChatRequest)
+	if !ok {
+		return request
+	}
+
+	resp, ok := response.(*cohere.NonStreamedChatResponse)
+	if !ok || resp == nil {
+		return request
+	}
+
+	// the text of tool call responses is the model's plan (or empty), so
+	// send back the extracted JSON instead
+	chatbotText := resp.Text
+	if chatbotText == "" || len(resp.ToolCalls) > 0 {
+		chatbotText = text
+	}
+
+	reask := *req
+
+	reask.ChatHistory = appendCopy(req.ChatHistory,
+		&cohere.Message{
+			Role: "USER",
+			User: &cohere.ChatMessage{Message: req.Message},
+		},
+		&cohere.Message{
+			Role:    "CHATBOT",
+			Chatbot: &cohere.ChatMessage{Message: chatbotText},
+		},
+	)
+	reask.Message = reaskPrompt(err)
+
+	return &reask
+}
+
 func (i *InstructorCohere) emptyResponseWithUsageSum(usage *UsageSum) interface{} {
 	return &cohere.NonStreamedChatResponse{
 		Meta: &cohere.ApiMeta{
@@ -119,7 +237,7 @@ func (i *InstructorCohere) addUsageSumToResponse(response interface{}, usage *Us
 
 func (i *InstructorCohere) countUsageFromResponse(response interface{}, usage *UsageSum) *UsageSum {
 	resp, ok := response.(*cohere.NonStreamedChatResponse)
-	if !ok {
+	if !ok || resp == nil || resp.Meta == nil || resp.Meta.Tokens == nil {
 		return usage
 	}
 
@@ -129,23 +247,160 @@ func (i *InstructorCohere) countUsageFromResponse(response interface{}, usage *U
 	return usage
 }
 
-func createCohereTools(schema *Schema) *cohere.Tool {
+// One tool per function of the schema, with a parameter per field
+func createCohereTools(schema *Schema) []*cohere.Tool {
+	tools := make([]*cohere.Tool, 0, len(schema.Functions))
 
-	tool := &cohere.Tool{
-		Name:                 "functions",
-		Description:          schema.Schema.Description,
-		ParameterDefinitions: make(map[string]*cohere.ToolParameterDefinitionsValue),
+	for _, function := range schema.Functions {
+		tool := &cohere.Tool{
+			Name:                 function.Name,
+			Description:          function.Description,
+			ParameterDefinitions: make(map[string]*cohere.ToolParameterDefinitionsValue),
+		}
+		if tool.Description == "" {
+			tool.Description = "Respond with " + function.Name
+		}
+
+		required := map[string]bool{}
+		for _, name := range function.Parameters.Required {
+			required[name] = true
+		}
+
+		if properties := function.Parameters.Properties; properties != nil {
+			for pair := properties.Oldest(); pair != nil; pair = pair.Next() {
+				tool.ParameterDefinitions[pair.Key] = cohereParameterDefinition(pair.Value, schema.Definitions, required[pair.Key])
+			}
+		}
+
+		tools = append(tools, tool)
 	}
 
-	for _, function := range schema.Functions {
-		parameterDefinition := &cohere.ToolParameterDefinitionsValue{
-			Description: toPtr(function.Description),
-			Type:        function.Parameters.Type,
+	return tools
+}
+
+func cohereParameterDefinition(property *jsonschema.Schema, definitions jsonschema.Definitions, required bool) *cohere.ToolParameterDefinitionsValue {
+	resolved := resolveSchemaRef(property, definitions)
+
+	description := property.Description
+	if description == "" {
+		description = resolved.Description
+	}
+	if description == "" {
+		description = property.Title
+	}
+
+	parameterType := cohereParameterType(resolved, definitions)
+
+	// Python types don't describe the fields of objects, add their schema
+	// with the referenced definitions inlined, $defs is not sent
+	if strings.Contains(parameterType, "Dict") {
+		if schemaJSON, err := json.Marshal(inlineSchemaRefs(resolved, definitions, map[string]bool{})); err == nil {
+			description = strings.TrimSpace(description + " JSON schema: " + string(schemaJSON))
 		}
-		tool.ParameterDefinitions[function.Name] = parameterDefinition
 	}
 
-	return tool
+	definition := &cohere.ToolParameterDefinitionsValue{
+		Type:     parameterType,
+		Required: toPtr(required),
+	}
+	if description != "" {
+		definition.Description = toPtr(description)
+	}
+
+	return definition
+}
+
+// Maps a JSON schema type to the Python type expected by Cohere
+func cohereParameterType(property *jsonschema.Schema, definitions jsonschema.Definitions) string {
+	property = resolveSchemaRef(property, definitions)
+
+	switch property.Type {
+	case "string":
+		return "str"
+	case "integer":
+		return "int"
+	case "number":
+		return "float"
+	case "boolean":
+		return "bool"
+	case "array":
+		if property.Items == nil {
+			return "List"
+		}
+		return "List[" + cohereParameterType(property.Items, definitions) + "]"
+	default:
+		return "Dict"
+	}
+}
+
+// Definition referenced by the schema, ex: '#/$defs/MyStruct', or the schema itself
+func resolveSchemaRef(schema *jsonschema.Schema, definitions jsonschema.Definitions) *jsonschema.Schema {
+	if schema.Ref == "" {
+		return schema
+	}
+	if definition, ok := definitions[strings.TrimPrefix(schema.Ref, "#/$defs/")]; ok {
+		return definition
+	}
+	return schema
+}
+
+// Copy of the schema with its references replaced by the referenced
+// definitions, recursively. A definition referencing itself, directly or not,
+// is cut off at the reference as an object without properties.
+func inlineSchemaRefs(schema *jsonschema.Schema, definitions jsonschema.Definitions, inlining map[string]bool) *jsonschema.Schema {
+	if schema == nil {
+		return nil
+	}
+
+	if schema.Ref != "" {
+		name := strings.TrimPrefix(schema.Ref, "#/$defs/")
+		definition, ok := definitions[name]
+		if !ok {
+			return schema
+		}
+		if inlining[name] {
+			return &jsonschema.Schema{Type: "object", Title: definition.Title, Description: definition.Description}
+		}
+
+		inlining[name] = true
+		defer delete(inlining, name)
+
+		inlined := inlineSchemaRefs(definition, definitions, inlining)
+		if schema.Description != "" {
+			inlined.Description = schema.Description
+		}
+		return inlined
+	}
+
+	inlined := *schema
+	inlined.Definitions = nil
+	inlined.Items = inlineSchemaRefs(schema.Items, definitions, inlining)
+	inlined.AdditionalProperties = inlineSchemaRefs(schema.AdditionalProperties, definitions, inlining)
+	inlined.PrefixItems = inlineSchemaRefList(schema.PrefixItems, definitions, inlining)
+	inlined.AllOf = inlineSchemaRefList(schema.AllOf, definitions, inlining)
+	inlined.AnyOf = inlineSchemaRefList(schema.AnyOf, definitions, inlining)
+	inlined.OneOf = inlineSchemaRefList(schema.OneOf, definitions, inlining)
+
+	if schema.Properties != nil {
+		inlined.Properties = jsonschema.NewProperties()
+		for pair := schema.Properties.Oldest(); pair != nil; pair = pair.Next() {
+			inlined.Properties.Set(pair.Key, inlineSchemaRefs(pair.Value, definitions, inlining))
+		}
+	}
+
+	return &inlined
+}
+
+func inlineSchemaRefList(schemas []*jsonschema.Schema, definitions jsonschema.Definitions, inlining map[string]bool) []*jsonschema.Schema {
+	if schemas == nil {
+		return nil
+	}
+
+	inlined := make([]*jsonschema.Schema, len(schemas))
+	for idx, schema := range schemas {
+		inlined[idx] = inlineSchemaRefs(schema, definitions, inlining)
+	}
+	return inlined
 }
 
 func nilCohereRespWithUsage(resp *cohere.NonStreamedChatResponse) *cohere.NonStreamedChatResponse {
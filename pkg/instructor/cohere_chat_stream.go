@@ -2,47 +2,73 @@ package instructor
 
 import (
 	"context"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"io"
+	"strings"
 
 	cohere "github.com/cohere-ai/cohere-go/v2"
-	option "github.com/cohere-ai/cohere-go/v2/option"
 )
 
 func (i *InstructorCohere) ChatStream(
 	ctx context.Context,
 	request *cohere.ChatStreamRequest,
 	responseType any,
-	opts ...option.RequestOption,
-) (<-chan any, error) {
-
-	stream, err := chatStreamHandler(i, ctx, request, responseType)
-	if err != nil {
-		return nil, err
-	}
+	opts ...Options,
+) (*Stream[any], error) {
+	return chatStreamHandler(i.withOptions(opts...), ctx, request, responseType)
+}
 
-	return stream, err
+// ChatPartialStream streams progressively filled copies of each response, see PartialResponse
+func (i *InstructorCohere) ChatPartialStream(
+	ctx context.Context,
+	request *cohere.ChatStreamRequest,
+	responseType any,
+	opts ...Options,
+) (*Stream[*PartialResponse], error) {
+	return chatPartialStreamHandler(i.withOptions(opts...), ctx, request, responseType)
 }
 
-func (i *InstructorCohere) chatStream(ctx context.Context, request interface{}, schema *Schema) (<-chan string, error) {
+func (i *InstructorCohere) chatStream(ctx context.Context, request interface{}, schema *Schema) (<-chan streamChunk, error) {
 
 	req, ok := request.(*cohere.ChatStreamRequest)
 	if !ok {
 		return nil, fmt.Errorf("invalid request type for %s client", i.Provider())
 	}
 
+	// copy so that prompts and tools added for this stream do not leak into the caller's request
+	req = toPtr(*req)
+
 	switch i.Mode() {
+	case ModeToolCall:
+		return i.chatToolCallStream(ctx, req, schema)
 	case ModeJSON:
 		return i.chatJSONStream(ctx, req, schema)
+	case ModeMarkdownJSON:
+		return i.chatMarkdownJSONStream(ctx, req, schema)
 	default:
-		return nil, fmt.Errorf("mode '%s' is not supported for %s", i.Mode(), i.Provider())
+		return nil, &UnsupportedModeError{Provider: i.Provider(), Mode: i.Mode()}
+	}
+}
+
+func (i *InstructorCohere) chatToolCallStream(ctx context.Context, request *cohere.ChatStreamRequest, schema *Schema) (<-chan streamChunk, error) {
+	request.Tools = createCohereTools(schema)
+	if i.ForceToolCall() {
+		// the Cohere chat API has no tool_choice, so the model is instructed instead
+		request.Preamble = concatCoherePreamble(request.Preamble, toolChoicePrompt(schema))
 	}
+	return i.createStream(ctx, request, schema)
 }
 
-func (i *InstructorCohere) chatJSONStream(ctx context.Context, request *cohere.ChatStreamRequest, schema *Schema) (<-chan string, error) {
+func (i *InstructorCohere) chatJSONStream(ctx context.Context, request *cohere.ChatStreamRequest, schema *Schema) (<-chan streamChunk, error) {
 	i.addOrConcatJSONSystemPromptStream(request, schema)
-	return i.createStream(ctx, request)
+	return i.createStream(ctx, request, schema)
+}
+
+func (i *InstructorCohere) chatMarkdownJSONStream(ctx context.Context, request *cohere.ChatStreamRequest, schema *Schema) (<-chan streamChunk, error) {
+	request.Preamble = concatCoherePreamble(request.Preamble, markdownJSONPrompt(schema))
+	return i.createStream(ctx, request, schema)
 }
 
 func (i *InstructorCohere) addOrConcatJSONSystemPromptStream(request *cohere.ChatStreamRequest, schema *Schema) {
@@ -56,13 +82,15 @@ func (i *InstructorCohere) addOrConcatJSONSystemPromptStream(request *cohere.Cha
 	}
 }
 
-func (i *InstructorCohere) createStream(ctx context.Context, request *cohere.ChatStreamRequest) (<-chan string, error) {
+func (i *InstructorCohere) createStream(ctx context.Context, request *cohere.ChatStreamRequest, schema *Schema) (<-chan streamChunk, error) {
 	stream, err := i.Client.ChatStream(ctx, request)
 	if err != nil {
-		return nil, err
+		return nil, newProviderError(i.Provider(), err)
 	}
 
-	ch := make(chan string)
+	toolCall := i.Mode() == ModeToolCall
+
+	ch := make(chan streamChunk)
 
 	go func() {
 		defer stream.Close()
@@ -73,19 +101,102 @@ func (i *InstructorCohere) createStream(ctx context.Context, request *cohere.Cha
 				return
 			}
 			if err != nil {
+				sendChunk(ctx, ch, streamChunk{Err: newProviderError(i.Provider(), err)})
 				return
 			}
+
+			var chunk streamChunk
+
 			switch message.EventType {
-			case "stream-start":
-				continue
+			case "text-generation":
+				// in ModeToolCall the text is the model's plan, the elements are the tool calls
+				if toolCall || message.TextGeneration == nil {
+					continue
+				}
+				chunk.Text = message.TextGeneration.Text
+			case "tool-calls-generation":
+				if !toolCall || message.ToolCallsGeneration == nil {
+					continue
+				}
+				chunk.Text, err = cohereToolCallsText(message.ToolCallsGeneration.ToolCalls, schema)
+				if err != nil {
+					sendChunk(ctx, ch, streamChunk{Err: err})
+					return
+				}
 			case "stream-end":
+				chunk.Usage = cohereStreamUsage(message.StreamEnd)
+				chunk.Err = cohereStreamEndError(message.StreamEnd)
+				sendChunk(ctx, ch, chunk)
 				return
-			case "text-generation":
-				ch <- message.TextGeneration.Text
 			default:
-				panic(errors.New("cohere streaming event type not supported by instructor: " + message.EventType))
+				// stream-start, search-queries-generation, search-results,
+				// citation-generation and events added to the API later carry
+				// nothing to extract
+				continue
+			}
+
+			if !sendChunk(ctx, ch, chunk) {
+				return
 			}
 		}
 	}()
 	return ch, nil
 }
+
+// The tool calls as a StreamWrapper, with an element per tool call
+func cohereToolCallsText(toolCalls []*cohere.ToolCall, schema *Schema) (string, error) {
+	calls := newToolCallStream(schema.streamWrapper)
+
+	text := new(strings.Builder)
+	for index, toolCall := range toolCalls {
+		if toolCall == nil {
+			continue
+		}
+
+		parameters, err := json.Marshal(toolCall.Parameters)
+		if err != nil {
+			return "", err
+		}
+		text.WriteString(calls.add(index, string(parameters)))
+	}
+	text.WriteString(calls.end())
+
+	return text.String(), nil
+}
+
+// Error for streams that ended without a finished reply, MAX_TOKENS is left
+// to the parser, which drops the cut off element
+func cohereStreamEndError(event *cohere.ChatStreamEndEvent) error {
+	if event == nil {
+		return nil
+	}
+
+	switch event.FinishReason {
+	case cohere.ChatStreamEndEventFinishReasonError,
+		cohere.ChatStreamEndEventFinishReasonErrorLimit,
+		cohere.ChatStreamEndEventFinishReasonErrorToxic:
+		return newProviderError(ProviderCohere, fmt.Errorf("stream ended with finish reason %s", event.FinishReason))
+	default:
+		return nil
+	}
+}
+
+// Tokens billed for the stream, from the meta of the consolidated response
+func cohereStreamUsage(event *cohere.ChatStreamEndEvent) *UsageSum {
+	if event == nil || event.Response == nil || event.Response.Meta == nil || event.Response.Meta.Tokens == nil {
+		return nil
+	}
+
+	tokens := event.Response.Meta.Tokens
+
+	usage := &UsageSum{}
+	if tokens.InputTokens != nil {
+		usage.InputTokens = int(*tokens.InputTokens)
+	}
+	if tokens.OutputTokens != nil {
+		usage.OutputTokens = int(*tokens.OutputTokens)
+	}
+	usage.TotalTokens = usage.InputTokens + usage.OutputTokens
+
+	return usage
+}
@@ -0,0 +1,144 @@
+package instructor
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+
+	cohere "github.com/cohere-ai/cohere-go/v2"
+)
+
+type cohereTestAddress struct {
+	City string `json:"city"`
+}
+
+type cohereTestPerson struct {
+	Name    string              `json:"name"`
+	Address cohereTestAddress   `json:"address"`
+	Friends []*cohereTestPerson `json:"friends"`
+}
+
+type cohereTestGroup struct {
+	People []cohereTestPerson `json:"people"`
+}
+
+func TestCreateCohereToolsInlinesRefs(t *testing.T) {
+	schema, err := NewSchema(reflect.TypeOf(cohereTestGroup{}))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var people *cohere.ToolParameterDefinitionsValue
+	for _, tool := range createCohereTools(schema) {
+		if tool.Name == "cohereTestGroup" {
+			people = tool.ParameterDefinitions["people"]
+		}
+	}
+	if people == nil {
+		t.Fatal("got no people parameter")
+	}
+	if people.Type != "List[Dict]" {
+		t.Errorf("got type %q, want %q", people.Type, "List[Dict]")
+	}
+	if people.Description == nil {
+		t.Fatal("got no description, want the JSON schema of the people")
+	}
+
+	description := *people.Description
+	if strings.Contains(description, "$ref") || strings.Contains(description, "$defs") {
+		t.Errorf("got references in the description: %s", description)
+	}
+	for _, field := range []string{`"name"`, `"city"`, `"friends"`} {
+		if !strings.Contains(description, field) {
+			t.Errorf("got no %s field in the description: %s", field, description)
+		}
+	}
+}
+
+func TestCohereStreamUsage(t *testing.T) {
+	tests := []struct {
+		name  string
+		event *cohere.ChatStreamEndEvent
+		want  *UsageSum
+	}{
+		{
+			name:  "no meta",
+			event: &cohere.ChatStreamEndEvent{Response: &cohere.NonStreamedChatResponse{}},
+		},
+		{
+			name: "tokens",
+			event: &cohere.ChatStreamEndEvent{Response: &cohere.NonStreamedChatResponse{Meta: &cohere.ApiMeta{
+				Tokens: &cohere.ApiMetaTokens{InputTokens: toPtr(3.0), OutputTokens: toPtr(4.0)},
+			}}},
+			want: &UsageSum{InputTokens: 3, OutputTokens: 4, TotalTokens: 7},
+		},
+		{
+			name: "input tokens only",
+			event: &cohere.ChatStreamEndEvent{Response: &cohere.NonStreamedChatResponse{Meta: &cohere.ApiMeta{
+				Tokens: &cohere.ApiMetaTokens{InputTokens: toPtr(3.0)},
+			}}},
+			want: &UsageSum{InputTokens: 3, TotalTokens: 3},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := cohereStreamUsage(tt.event); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCohereAddReaskMessages(t *testing.T) {
+	reaskErr := &DecodeError{Err: errors.New("unexpected end of JSON input")}
+	history := &cohere.Message{Role: "SYSTEM", System: &cohere.ChatMessage{Message: "be brief"}}
+
+	tests := []struct {
+		name     string
+		response *cohere.NonStreamedChatResponse
+		want     string
+	}{
+		{
+			name:     "text",
+			response: &cohere.NonStreamedChatResponse{Text: `{"name":`},
+			want:     `{"name":`,
+		},
+		{
+			name: "tool calls",
+			response: &cohere.NonStreamedChatResponse{
+				Text:      "I will call Person",
+				ToolCalls: []*cohere.ToolCall{{Name: "Person", Parameters: map[string]interface{}{"name": 1}}},
+			},
+			want: `{"name": 1}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// spare capacity, appending must still copy
+			chatHistory := make([]*cohere.Message, 1, 4)
+			chatHistory[0] = history
+			request := &cohere.ChatRequest{Message: "person", ChatHistory: chatHistory}
+
+			client := FromCohere(nil)
+			got := client.addReaskMessages(request, tt.response, tt.want, reaskErr).(*cohere.ChatRequest)
+
+			want := []*cohere.Message{
+				history,
+				{Role: "USER", User: &cohere.ChatMessage{Message: "person"}},
+				{Role: "CHATBOT", Chatbot: &cohere.ChatMessage{Message: tt.want}},
+			}
+			if !reflect.DeepEqual(got.ChatHistory, want) {
+				t.Errorf("got %+v, want %+v", got.ChatHistory, want)
+			}
+			if got.Message != reaskPrompt(reaskErr) {
+				t.Errorf("got message %q, want the reask prompt", got.Message)
+			}
+			if request.Message != "person" || chatHistory[:2][1] != nil {
+				t.Error("got the caller's request modified")
+			}
+		})
+	}
+}
@@ -6,40 +6,27 @@ import (
 
 type InstructorCohere struct {
 	*cohere.Client
-
-	provider   Provider
-	mode       Mode
-	maxRetries int
-	validate   bool
+	clientOptions
 }
 
 var _ Instructor = &InstructorCohere{}
 
 func FromCohere(client *cohere.Client, opts ...Options) *InstructorCohere {
-
-	options := mergeOptions(opts...)
-
-	i := &InstructorCohere{
-		Client: client,
-
-		provider:   ProviderCohere,
-		mode:       *options.Mode,
-		maxRetries: *options.MaxRetries,
+	return &InstructorCohere{
+		Client:        client,
+		clientOptions: newClientOptions(ProviderCohere, opts...),
 	}
-	return i
 }
 
-func (i *InstructorCohere) Provider() string {
-	return i.provider
-}
-
-func (i *InstructorCohere) Mode() string {
-	return i.mode
+// Copy of the client with per-call options merged over its options
+func (i *InstructorCohere) withOptions(opts ...Options) *InstructorCohere {
+	if len(opts) == 0 {
+		return i
+	}
+	return &InstructorCohere{Client: i.Client, clientOptions: i.with(opts...)}
 }
 
-func (i *InstructorCohere) MaxRetries() int {
-	return i.maxRetries
-}
-func (i *InstructorCohere) Validate() bool {
-	return i.validate
+// Copy running the secondary calls of LLM validation, see Options.forValidation
+func (i *InstructorCohere) validationInstructor() Instructor {
+	return &InstructorCohere{Client: i.Client, clientOptions: i.forValidation()}
 }
@@ -0,0 +1,113 @@
+package instructor
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// Attempt records one try of an extraction that failed to decode or validate
+type Attempt struct {
+	// Raw text generated by the model
+	Text string
+	// Why the text was rejected, a *DecodeError or *ValidationError
+	Err error
+	// Token usage of this attempt only
+	Usage UsageSum
+	// Model the attempt was escalated to, empty without model escalation
+	Model string
+}
+
+// MaxRetriesExceededError is returned when no attempt produced a response
+// that decodes and validates. Unwrap returns the error of the last attempt.
+type MaxRetriesExceededError struct {
+	Attempts []Attempt
+}
+
+func (e *MaxRetriesExceededError) Error() string {
+	if len(e.Attempts) == 0 {
+		return "hit max retry attempts"
+	}
+	return fmt.Sprintf("hit max retry attempts (%d): %v", len(e.Attempts), e.Unwrap())
+}
+
+func (e *MaxRetriesExceededError) Unwrap() error {
+	if len(e.Attempts) == 0 {
+		return nil
+	}
+	return e.Attempts[len(e.Attempts)-1].Err
+}
+
+// Err of the DecodeError of responses without the tool calls of the schema
+var errNoToolCalls = errors.New("received no tool calls from model, expected at least 1")
+
+// DecodeError is returned when the model output is not valid JSON for the response type
+type DecodeError struct {
+	Text string
+	Err  error
+}
+
+func (e *DecodeError) Error() string {
+	return fmt.Sprintf("failed to decode model output: %v", e.Err)
+}
+
+func (e *DecodeError) Unwrap() error {
+	return e.Err
+}
+
+// ValidationError is returned when the decoded response fails validation,
+// either from `validate` struct tags, a Validate() method or an LLM rule
+type ValidationError struct {
+	Err error
+}
+
+func (e *ValidationError) Error() string {
+	return "response failed validation: " + strings.Join(e.Messages(), "; ")
+}
+
+// Messages returns one human-readable message per failure, addressing values
+// by their JSON path, ex: "addresses[1].phone must be a valid phone number"
+func (e *ValidationError) Messages() []string {
+	return errorMessages(e.Err)
+}
+
+func (e *ValidationError) Unwrap() error {
+	return e.Err
+}
+
+// ProviderError wraps an error returned by the OpenAI, Anthropic or Cohere client
+type ProviderError struct {
+	Provider Provider
+	// HTTP status code of the failed request, 0 if unknown
+	StatusCode int
+	Err        error
+	// Attempts rejected before the request failed, set by the extraction
+	Attempts []Attempt
+}
+
+func newProviderError(provider Provider, err error) *ProviderError {
+	statusCode, _ := statusCodeFromError(err)
+	return &ProviderError{
+		Provider:   provider,
+		StatusCode: statusCode,
+		Err:        err,
+	}
+}
+
+func (e *ProviderError) Error() string {
+	return fmt.Sprintf("%s request failed: %v", e.Provider, e.Err)
+}
+
+func (e *ProviderError) Unwrap() error {
+	return e.Err
+}
+
+// UnsupportedModeError is returned when a provider does not implement the selected mode
+type UnsupportedModeError struct {
+	Provider Provider
+	Mode     Mode
+}
+
+func (e *UnsupportedModeError) Error() string {
+	return fmt.Sprintf("mode '%s' is not supported for %s", e.Mode, e.Provider)
+}
@@ -0,0 +1,27 @@
+package instructor
+
+// ModelEscalation switches the request to a stronger model when extraction
+// keeps failing, ex: start with a cheap model and only pay for a bigger one
+// when needed. The number of attempts is still bounded by MaxRetries.
+//
+// Pass WithModelReport to get the model that produced the result, the same
+// way for every provider.
+type ModelEscalation struct {
+	// Models tried in order, ex: []string{"gpt-4o-mini", "gpt-4o"}
+	Models []string
+	// Failed attempts with a model before switching to the next one
+	AttemptsPerModel int
+	// Called when an attempt starts with another model (including the first), optional
+	OnEscalate func(model string)
+}
+
+// Model used for the attempt (0 based), empty when there are no models
+func (e *ModelEscalation) modelFor(attempt int) string {
+	if e == nil || len(e.Models) == 0 {
+		return ""
+	}
+
+	idx := attempt / max(e.AttemptsPerModel, 1)
+
+	return e.Models[min(idx, len(e.Models)-1)]
+}
@@ -2,17 +2,22 @@ package instructor
 
 import (
 	"context"
+	"reflect"
 
 	"github.com/go-playground/validator/v10"
 )
 
-var validate *validator.Validate
-
 type Instructor interface {
 	Provider() Provider
 	Mode() Mode
 	MaxRetries() int
+	RetryPolicy() RetryPolicy
+	PartialAcceptance() *PartialAcceptance
+	ModelEscalation() *ModelEscalation
 	Validate() bool
+	Validator() *validator.Validate
+	LLMRules(responseType reflect.Type) []LLMRule
+	ForceToolCall() bool
 
 	// Chat / Messages
 
@@ -28,7 +33,21 @@ type Instructor interface {
 		ctx context.Context,
 		request interface{},
 		schema *Schema,
-	) (<-chan string, error)
+	) (<-chan streamChunk, error)
+
+	// Model escalation
+
+	setModel(request interface{}, model string) interface{}
+	reportModel(model string)
+
+	// LLM validation
+
+	validationRequest(request interface{}, prompt string) interface{}
+	validationInstructor() Instructor
+
+	// Reasking
+
+	addReaskMessages(request interface{}, response interface{}, text string, err error) interface{}
 
 	// Usage counting
 
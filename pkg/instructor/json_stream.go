@@ -0,0 +1,389 @@
+package instructor
+
+import (
+	"encoding/json"
+)
+
+// jsonElementScanner incrementally splits streamed model output into the
+// elements of its items array, ex: `{"items": [{"a": 1}, "b", [2, 3]]}`. Text
+// before the JSON is skipped, a top-level array is read as the items array
+// when its first element starts a JSON value, so brackets in the text before
+// are not taken for it. Braces in the text before are dropped once they turn
+// out not to open an object, or an object without items. Every byte is
+// scanned once (but for a few bytes of look-ahead after a [, and the text
+// after a { that is not an object), string literals and escapes are respected.
+type jsonElementScanner struct {
+	buf []byte
+	// next byte to scan
+	pos   int
+	state jsonScanState
+
+	// nesting of objects / arrays: of the wrapper object while seeking the
+	// items array, of the current element while in it
+	depth    int
+	inString bool
+	escaped  bool
+
+	// seeking the items array in the wrapper object
+	wrapperStart int
+	expectKey    bool
+	isKey        bool
+	keyStart     int
+	key          string
+	wantColon    bool
+	afterColon   bool
+
+	// whether the items array was found
+	foundItems bool
+	// start of the current element, -1 between elements
+	elementStart int
+	// whether the current element is a string, number or literal
+	scalar bool
+	// in a number or literal nested in the current element
+	inLiteral bool
+	// strings, numbers and literals completed and objects and arrays opened
+	// or closed in the elements so far, changes when the parsed content of
+	// the pending element does
+	events int
+	// input ended, a trailing scalar element is complete
+	ended bool
+}
+
+type jsonScanState int
+
+const (
+	// skipping text until the JSON starts
+	scanSeek jsonScanState = iota
+	// in the wrapper object, looking for the items key
+	scanWrapper
+	// in the items array
+	scanElements
+	// the items array is closed
+	scanDone
+)
+
+func newJSONElementScanner() *jsonElementScanner {
+	return &jsonElementScanner{elementStart: -1}
+}
+
+func (s *jsonElementScanner) write(text string) {
+	s.buf = append(s.buf, text...)
+}
+
+// end marks the end of the input
+func (s *jsonElementScanner) end() {
+	s.ended = true
+}
+
+// started reports whether the items array was found
+func (s *jsonElementScanner) started() bool {
+	return s.foundItems
+}
+
+// pending returns the text of the element being generated, if any
+func (s *jsonElementScanner) pending() string {
+	if s.state != scanElements || s.elementStart < 0 {
+		return ""
+	}
+	return string(s.buf[s.elementStart:])
+}
+
+// next scans the new input up to the end of the next element of the items
+// array, returning it if complete
+func (s *jsonElementScanner) next() (element string, found bool) {
+	for s.pos < len(s.buf) {
+		switch s.state {
+		case scanSeek:
+			if !s.seek() {
+				// more input is needed to tell whether a [ opens the JSON
+				return "", false
+			}
+		case scanWrapper:
+			s.scanWrapper()
+		case scanElements:
+			if element, found = s.scanElement(); found {
+				s.compact()
+				return element, true
+			}
+		case scanDone:
+			// ignore the text after the JSON
+			s.pos = len(s.buf)
+		}
+	}
+
+	// a number or literal is only complete once followed by a delimiter,
+	// or at the end of the input
+	if s.ended && s.state == scanElements && s.elementStart >= 0 && s.scalar && !s.inString {
+		element = string(s.buf[s.elementStart:])
+		if json.Valid([]byte(element)) {
+			s.elementStart = -1
+			s.state = scanDone
+			return element, true
+		}
+	}
+
+	return "", false
+}
+
+// Drops the scanned input, keeping the element in progress
+func (s *jsonElementScanner) compact() {
+	keep := s.pos
+	if s.elementStart >= 0 {
+		keep = s.elementStart
+		s.elementStart = 0
+	}
+
+	s.buf = append(s.buf[:0], s.buf[keep:]...)
+	s.pos -= keep
+}
+
+// Skips the text before the JSON, returning false when more input is needed
+func (s *jsonElementScanner) seek() bool {
+	for ; s.pos < len(s.buf); s.pos++ {
+		switch s.buf[s.pos] {
+		case '[':
+			opens, wait := s.opensArray(s.pos + 1)
+			if wait {
+				return false
+			}
+			if !opens {
+				continue
+			}
+			// a top-level array holds the elements directly
+			s.pos++
+			s.state = scanElements
+			s.foundItems = true
+			return true
+		case '{':
+			s.wrapperStart = s.pos
+			s.pos++
+			s.state = scanWrapper
+			s.depth = 1
+			s.expectKey = true
+			return true
+		}
+	}
+	return true
+}
+
+// Whether the [ before start opens an array of elements rather than being
+// part of the text before the JSON, ex: "I think [this] is". wait is set when
+// the input so far cannot tell.
+func (s *jsonElementScanner) opensArray(start int) (opens bool, wait bool) {
+	i := start
+	for i < len(s.buf) && isJSONWhitespace(s.buf[i]) {
+		i++
+	}
+	if i == len(s.buf) {
+		return false, !s.ended
+	}
+
+	var literal string
+	switch c := s.buf[i]; {
+	case c == '{' || c == '[' || c == '"' || c == '-' || (c >= '0' && c <= '9'):
+		return true, false
+	case c == 't':
+		literal = "true"
+	case c == 'f':
+		literal = "false"
+	case c == 'n':
+		literal = "null"
+	default:
+		// an empty array holds no elements either, so it is skipped as well
+		return false, false
+	}
+
+	// the literal and the delimiter after it
+	rest := string(s.buf[i:min(len(s.buf), i+len(literal)+1)])
+	if len(rest) <= len(literal) {
+		if rest != literal[:len(rest)] {
+			return false, false
+		}
+		// a complete literal still needs the delimiter after it
+		return s.ended && rest == literal, !s.ended
+	}
+
+	if rest[:len(literal)] != literal {
+		return false, false
+	}
+	c := rest[len(literal)]
+	return isJSONWhitespace(c) || c == ',' || c == ']', false
+}
+
+func (s *jsonElementScanner) scanWrapper() {
+	for ; s.pos < len(s.buf); s.pos++ {
+		c := s.buf[s.pos]
+
+		if s.inString {
+			switch {
+			case s.escaped:
+				s.escaped = false
+			case c == '\\':
+				s.escaped = true
+			case c == '"':
+				s.inString = false
+				if s.isKey {
+					s.key = string(s.buf[s.keyStart+1 : s.pos])
+					s.isKey = false
+					s.wantColon = true
+				}
+			}
+			continue
+		}
+
+		if isJSONWhitespace(c) {
+			continue
+		}
+
+		if s.depth == 1 && !s.continuesWrapper(c) {
+			// not an object, ex: "use {braces} here", seek again after the {
+			s.pos = s.wrapperStart + 1
+			s.resetWrapper()
+			return
+		}
+
+		if s.depth == 1 && s.afterColon {
+			s.afterColon = false
+			if c == '[' && s.key == "items" {
+				s.pos++
+				s.state = scanElements
+				s.foundItems = true
+				s.depth = 0
+				return
+			}
+		}
+
+		switch c {
+		case '"':
+			s.inString = true
+			s.isKey = s.depth == 1 && s.expectKey
+			s.keyStart = s.pos
+		case ':':
+			if s.depth == 1 {
+				s.afterColon = true
+				s.expectKey = false
+				s.wantColon = false
+			}
+		case ',':
+			if s.depth == 1 {
+				s.expectKey = true
+				s.key = ""
+			}
+		case '{', '[':
+			s.depth++
+		case '}', ']':
+			s.depth--
+			if s.depth == 0 {
+				// the wrapper ended without an items array, the JSON may
+				// still follow, ex: "use {} here"
+				s.pos++
+				s.resetWrapper()
+				return
+			}
+		}
+	}
+}
+
+// Whether the byte, outside of strings, can follow in the wrapper object
+func (s *jsonElementScanner) continuesWrapper(c byte) bool {
+	switch {
+	case s.wantColon:
+		return c == ':'
+	case s.expectKey:
+		return c == '"' || c == '}'
+	default:
+		return true
+	}
+}
+
+// Goes back to seeking the JSON at pos
+func (s *jsonElementScanner) resetWrapper() {
+	s.state = scanSeek
+	s.depth = 0
+	s.expectKey = false
+	s.isKey = false
+	s.key = ""
+	s.wantColon = false
+	s.afterColon = false
+}
+
+func (s *jsonElementScanner) scanElement() (element string, found bool) {
+	for ; s.pos < len(s.buf); s.pos++ {
+		c := s.buf[s.pos]
+
+		if s.elementStart < 0 {
+			// between elements
+			switch {
+			case isJSONWhitespace(c) || c == ',':
+				continue
+			case c == ']' || c == '}':
+				s.pos++
+				s.state = scanDone
+				return "", false
+			}
+
+			s.elementStart = s.pos
+			s.depth = 0
+			s.scalar = c != '{' && c != '['
+			s.inLiteral = false
+		}
+
+		if s.inString {
+			switch {
+			case s.escaped:
+				s.escaped = false
+			case c == '\\':
+				s.escaped = true
+			case c == '"':
+				s.inString = false
+				s.events++
+				if s.depth == 0 {
+					// a string element
+					return s.completeElement(s.pos + 1), true
+				}
+			}
+			continue
+		}
+
+		if s.scalar && s.pos > s.elementStart && (isJSONWhitespace(c) || c == ',' || c == ']' || c == '}') {
+			// the delimiter after a number or literal, scanned again
+			// between elements
+			return s.completeElement(s.pos), true
+		}
+
+		delimiter := isJSONWhitespace(c) || c == ',' || c == ':' || c == '"' || c == '{' || c == '[' || c == '}' || c == ']'
+		if s.inLiteral && delimiter {
+			s.inLiteral = false
+			s.events++
+		} else if !s.scalar && !delimiter {
+			s.inLiteral = true
+		}
+
+		switch c {
+		case '"':
+			s.inString = true
+		case '{', '[':
+			s.depth++
+			s.events++
+		case '}', ']':
+			s.depth--
+			s.events++
+			if s.depth == 0 {
+				return s.completeElement(s.pos + 1), true
+			}
+		}
+	}
+
+	return "", false
+}
+
+func (s *jsonElementScanner) completeElement(end int) string {
+	element := string(s.buf[s.elementStart:end])
+	s.pos = end
+	s.elementStart = -1
+	return element
+}
+
+func isJSONWhitespace(c byte) bool {
+	return c == ' ' || c == '\t' || c == '\r' || c == '\n'
+}
@@ -0,0 +1,165 @@
+package instructor
+
+import (
+	"reflect"
+	"testing"
+)
+
+// Feeds the chunks to a scanner, returning every element it completes
+func scanAll(chunks []string) []string {
+	s := newJSONElementScanner()
+	elements := []string{}
+
+	for _, chunk := range chunks {
+		s.write(chunk)
+		for element, found := s.next(); found; element, found = s.next() {
+			elements = append(elements, element)
+		}
+	}
+
+	s.end()
+	for element, found := s.next(); found; element, found = s.next() {
+		elements = append(elements, element)
+	}
+
+	return elements
+}
+
+func TestJSONElementScanner(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		want  []string
+	}{
+		{
+			name:  "wrapper",
+			input: `{"items": [{"a": 1}, {"b": [2, 3]}]}`,
+			want:  []string{`{"a": 1}`, `{"b": [2, 3]}`},
+		},
+		{
+			name:  "top-level array",
+			input: `[{"a": 1}, "b"]`,
+			want:  []string{`{"a": 1}`, `"b"`},
+		},
+		{
+			name:  "escapes",
+			input: `{"items": ["a \"quoted\" word", "back\\", "é"]}`,
+			want:  []string{`"a \"quoted\" word"`, `"back\\"`, `"é"`},
+		},
+		{
+			name:  "braces inside strings",
+			input: `{"items": [{"text": "} ] { ["}, {"text": "\"}"}]}`,
+			want:  []string{`{"text": "} ] { ["}`, `{"text": "\"}"}`},
+		},
+		{
+			name:  "scalars",
+			input: `{"items": [1, -2.5e3, true, false, null, "s"]}`,
+			want:  []string{`1`, `-2.5e3`, `true`, `false`, `null`, `"s"`},
+		},
+		{
+			name:  "nested arrays",
+			input: `{"items": [[1, [2, 3]], [], [{"a": [4]}]]}`,
+			want:  []string{`[1, [2, 3]]`, `[]`, `[{"a": [4]}]`},
+		},
+		{
+			name:  "trailing scalar at EOF",
+			input: `[1, 2`,
+			want:  []string{`1`, `2`},
+		},
+		{
+			name:  "incomplete trailing scalar at EOF",
+			input: `[1, tr`,
+			want:  []string{`1`},
+		},
+		{
+			name:  "unfinished element",
+			input: `{"items": [{"a": 1}, {"b": `,
+			want:  []string{`{"a": 1}`},
+		},
+		{
+			name:  "leading prose",
+			input: "Sure, here you go:\n```json\n{\"items\": [1]}\n```",
+			want:  []string{`1`},
+		},
+		{
+			name:  "brackets in leading prose",
+			input: `I think [this] is [ ] right: {"items": [1]}`,
+			want:  []string{`1`},
+		},
+		{
+			name:  "literal in leading prose",
+			input: `[nothing] [true] `,
+			want:  []string{`true`},
+		},
+		{
+			name:  "other keys before items",
+			input: `{"note": "items: [0]", "other": {"items": [0]}, "items": [1]}`,
+			want:  []string{`1`},
+		},
+		{
+			name:  "text after the JSON",
+			input: `{"items": [1]} and [2]`,
+			want:  []string{`1`},
+		},
+		{
+			name:  "braces in leading prose",
+			input: `Use {braces} here: {"items": [1, 2]}`,
+			want:  []string{`1`, `2`},
+		},
+		{
+			name:  "unclosed brace in leading prose",
+			input: `Use { like this: {"items": [1]}`,
+			want:  []string{`1`},
+		},
+		{
+			name:  "quoted word in braces in leading prose",
+			input: `A {"quoted" word} then {"items": [1]}`,
+			want:  []string{`1`},
+		},
+		{
+			name:  "object without items before the JSON",
+			input: `{"other": 1} {"items": [2]}`,
+			want:  []string{`2`},
+		},
+		{
+			name:  "no items",
+			input: `{"other": {"items": [1]}}`,
+			want:  []string{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := scanAll([]string{tt.input}); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got %q, want %q", got, tt.want)
+			}
+
+			// one byte at a time, splitting multi-byte characters too
+			bytes := []string{}
+			for i := 0; i < len(tt.input); i++ {
+				bytes = append(bytes, tt.input[i:i+1])
+			}
+			if got := scanAll(bytes); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("byte by byte: got %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestJSONElementScannerPending(t *testing.T) {
+	s := newJSONElementScanner()
+
+	s.write(`{"items": [{"a": 1}, {"b": "x`)
+	if element, found := s.next(); !found || element != `{"a": 1}` {
+		t.Fatalf("got %q, %v, want the first element", element, found)
+	}
+	if _, found := s.next(); found {
+		t.Fatal("got an element, want none before the second one is complete")
+	}
+	if got, want := s.pending(), `{"b": "x`; got != want {
+		t.Errorf("got pending %q, want %q", got, want)
+	}
+	if !s.started() {
+		t.Error("got not started, want started")
+	}
+}
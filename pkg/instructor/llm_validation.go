@@ -0,0 +1,194 @@
+package instructor
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// Struct tag holding a natural-language rule checked by the model, ex:
+//
+//	Summary string `json:"summary" llmvalidate:"must not contain medical advice"`
+const LLMValidateTag = "llmvalidate"
+
+// LLMRule is a natural-language rule that is checked with a secondary
+// structured call to the model, ex: "reason must reference the product"
+type LLMRule struct {
+	// JSON path of the checked value with fields separated by dots, slices are
+	// walked implicitly, ex: "items.reason". Empty checks the whole response
+	Field string
+	Rule  string
+}
+
+// LLMVerdict is the structured answer of the model checking an LLMRule
+type LLMVerdict struct {
+	IsValid bool   `json:"is_valid" jsonschema:"title=Is Valid,description=Whether the value follows the rule"`
+	Reason  string `json:"reason"   jsonschema:"title=Reason,description=Explanation of why the value does or does not follow the rule"`
+}
+
+// LLMValidationError is returned for a value the model judged to break its rule
+type LLMValidationError struct {
+	Field  string
+	Rule   string
+	Reason string
+}
+
+func (e *LLMValidationError) Error() string {
+	if e.Field == "" {
+		return fmt.Sprintf("response does not follow the rule '%s': %s", e.Rule, e.Reason)
+	}
+	return fmt.Sprintf("%s does not follow the rule '%s': %s", e.Field, e.Rule, e.Reason)
+}
+
+// Checks every rule against the response with a secondary call to the model.
+// Broken rules are returned as failures, errors calling the model as err.
+func validateWithLLM(i Instructor, ctx context.Context, request interface{}, response any, rules []LLMRule, usage *UsageSum) (failures error, err error) {
+	if len(rules) == 0 {
+		return nil, nil
+	}
+
+	// rules address JSON paths, so walk the response as the model sees it
+	data, err := json.Marshal(response)
+	if err != nil {
+		return nil, err
+	}
+	var value any
+	if err = json.Unmarshal(data, &value); err != nil {
+		return nil, err
+	}
+
+	// validation calls neither escalate nor drop elements
+	validationClient := i.validationInstructor()
+
+	var errs []error
+
+	for _, rule := range rules {
+		for _, match := range valuesAtPath(value, "", splitPath(rule.Field)) {
+
+			field := match.path
+
+			fieldJSON, err := json.Marshal(match.value)
+			if err != nil {
+				return nil, err
+			}
+
+			verdict := &LLMVerdict{}
+			prompt := llmValidationPrompt(rule.Rule, field, string(fieldJSON))
+
+			resp, err := chatHandler(validationClient, ctx, i.validationRequest(request, prompt), verdict)
+			i.countUsageFromResponse(resp, usage)
+			if err != nil {
+				return nil, err
+			}
+
+			if !verdict.IsValid {
+				errs = append(errs, &LLMValidationError{Field: field, Rule: rule.Rule, Reason: verdict.Reason})
+			}
+		}
+	}
+
+	return errors.Join(errs...), nil
+}
+
+func llmValidationPrompt(rule, field, value string) string {
+	if field == "" {
+		field = "the response"
+	}
+	return fmt.Sprintf(`
+You are validating data extracted by another model.
+
+Rule: %s
+
+Value of %s:
+
+%s
+
+Decide whether the value follows the rule and explain why.
+`, rule, field, value)
+}
+
+func splitPath(path string) []string {
+	if path == "" {
+		return nil
+	}
+	return strings.Split(path, ".")
+}
+
+type pathValue struct {
+	path  string
+	value any
+}
+
+// Resolves the path in a decoded JSON value, returning the matches with
+// their full path, ex: "items[1].reason"
+func valuesAtPath(value any, prefix string, path []string) []pathValue {
+	if value == nil {
+		return nil
+	}
+
+	if len(path) == 0 {
+		return []pathValue{{path: prefix, value: value}}
+	}
+
+	switch v := value.(type) {
+	case []any:
+		matches := []pathValue{}
+		for idx, elem := range v {
+			matches = append(matches, valuesAtPath(elem, indexPath(prefix, idx), path)...)
+		}
+		return matches
+	case map[string]any:
+		return valuesAtPath(v[path[0]], fieldPath(prefix, path[0]), path[1:])
+	default:
+		return nil
+	}
+}
+
+// Rules declared with the llmvalidate struct tag, addressed by JSON path
+func llmRulesFromTags(t reflect.Type) []LLMRule {
+	return collectLLMRules(t, "", map[reflect.Type]bool{})
+}
+
+func collectLLMRules(t reflect.Type, prefix string, visiting map[reflect.Type]bool) []LLMRule {
+	for t.Kind() == reflect.Pointer || t.Kind() == reflect.Slice || t.Kind() == reflect.Array {
+		t = t.Elem()
+	}
+
+	if t.Kind() != reflect.Struct || visiting[t] {
+		return nil
+	}
+
+	// guard against recursive types
+	visiting[t] = true
+	defer delete(visiting, t)
+
+	rules := []LLMRule{}
+
+	for idx := 0; idx < t.NumField(); idx++ {
+		field := t.Field(idx)
+		if !field.IsExported() {
+			continue
+		}
+
+		name, inline := jsonFieldName(field)
+		if name == "-" {
+			continue
+		}
+
+		path := prefix
+		if !inline {
+			path = fieldPath(prefix, name)
+		}
+
+		if rule, ok := field.Tag.Lookup(LLMValidateTag); ok && rule != "" {
+			rules = append(rules, LLMRule{Field: path, Rule: rule})
+		}
+
+		rules = append(rules, collectLLMRules(field.Type, path, visiting)...)
+	}
+
+	return rules
+}
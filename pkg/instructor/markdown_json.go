@@ -0,0 +1,77 @@
+package instructor
+
+import (
+	"context"
+	"fmt"
+	"strings"
+)
+
+const (
+	markdownJSONFence = "```json"
+	markdownFence     = "```"
+)
+
+// Asks for the response in a fenced ```json block, for ModeMarkdownJSON
+func markdownJSONPrompt(schema *Schema) string {
+	return fmt.Sprintf(`
+Please respond with a JSON instance of the following JSON schema, in a markdown code block starting with %s and ending with %s:
+
+%s
+
+Make sure to return an instance of the JSON, not the schema itself. Any explanation goes outside of the code block.
+`, markdownJSONFence, markdownFence, schema.String)
+}
+
+// Extracts the JSON of the first fenced ```json block, falling back to
+// extractJSON when the text has none
+func extractMarkdownJSON(text *string) string {
+	_, block, found := strings.Cut(*text, markdownJSONFence)
+	if !found {
+		return extractJSON(text)
+	}
+
+	block, _, _ = strings.Cut(block, markdownFence)
+	return extractJSON(&block)
+}
+
+// Forwards the streamed text from the opening ```json fence on, so text
+// before the block is not scanned for JSON. Text after the block is ignored
+// by the scanner. When the stream ends without a fence, the held text is
+// forwarded as is. Usage and errors are passed through.
+func markdownJSONStream(ctx context.Context, ch <-chan streamChunk) <-chan streamChunk {
+	out := make(chan streamChunk)
+
+	go func() {
+		defer close(out)
+
+		held := new(strings.Builder)
+		inBlock := false
+
+		for chunk := range ch {
+			if !inBlock && chunk.Text != "" {
+				held.WriteString(chunk.Text)
+				chunk.Text = ""
+
+				if _, block, found := strings.Cut(held.String(), markdownJSONFence); found {
+					inBlock = true
+					chunk.Text = block
+					held.Reset()
+				}
+			}
+			if chunk.Err != nil {
+				// the stream failed, nothing follows
+				held.Reset()
+			}
+
+			if !sendChunk(ctx, out, chunk) {
+				return
+			}
+		}
+
+		if held.Len() > 0 {
+			sendChunk(ctx, out, streamChunk{Text: held.String()})
+		}
+	}()
+
+	return out
+}
@@ -0,0 +1,101 @@
+package instructor
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestExtractMarkdownJSON(t *testing.T) {
+	tests := []struct {
+		name string
+		text string
+		want string
+	}{
+		{
+			name: "block",
+			text: "Here you go:\n```json\n{\"name\": \"Ann\"}\n```\nAnything else?",
+			want: `{"name": "Ann"}`,
+		},
+		{
+			name: "braces before the block",
+			text: "The {name} field:\n```json\n{\"name\": \"Ann\"}\n```",
+			want: `{"name": "Ann"}`,
+		},
+		{
+			name: "first of two blocks",
+			text: "```json\n[1]\n```\nor\n```json\n[2]\n```",
+			want: `[1]`,
+		},
+		{
+			name: "unclosed block",
+			text: "```json\n{\"name\": \"Ann\"}",
+			want: `{"name": "Ann"}`,
+		},
+		{
+			name: "no block",
+			text: `Sure: {"name": "Ann"}`,
+			want: `{"name": "Ann"}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := extractMarkdownJSON(&tt.text); got != tt.want {
+				t.Errorf("got %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMarkdownJSONStream(t *testing.T) {
+	usage := &UsageSum{InputTokens: 1}
+	streamErr := errors.New("stream failed")
+
+	tests := []struct {
+		name   string
+		chunks []streamChunk
+		want   []streamChunk
+	}{
+		{
+			name:   "fence split over chunks",
+			chunks: []streamChunk{{Text: "The {name}:\n``"}, {Text: "`json\n{\"na"}, {Text: "me\": 1}\n```"}},
+			want:   []streamChunk{{}, {Text: "\n{\"na"}, {Text: "me\": 1}\n```"}},
+		},
+		{
+			name:   "no fence",
+			chunks: []streamChunk{{Text: `{"name":`}, {Text: ` 1}`}},
+			want:   []streamChunk{{}, {}, {Text: `{"name": 1}`}},
+		},
+		{
+			name:   "usage passed through",
+			chunks: []streamChunk{{Text: "```json\n[1]", Usage: usage}, {Usage: usage}},
+			want:   []streamChunk{{Text: "\n[1]", Usage: usage}, {Usage: usage}},
+		},
+		{
+			name:   "error drops the held text",
+			chunks: []streamChunk{{Text: "Thinking {"}, {Err: streamErr}},
+			want:   []streamChunk{{}, {Err: streamErr}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			in := make(chan streamChunk, len(tt.chunks))
+			for _, chunk := range tt.chunks {
+				in <- chunk
+			}
+			close(in)
+
+			got := []streamChunk{}
+			for chunk := range markdownJSONStream(context.Background(), in) {
+				got = append(got, chunk)
+			}
+
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
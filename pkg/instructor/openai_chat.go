@@ -22,9 +22,10 @@ func (i *InstructorOpenAI) CreateChatCompletion(
 	ctx context.Context,
 	request openai.ChatCompletionRequest,
 	responseType any,
+	opts ...Options,
 ) (response openai.ChatCompletionResponse, err error) {
 
-	resp, err := chatHandler(i, ctx, request, responseType)
+	resp, err := chatHandler(i.withOptions(opts...), ctx, request, responseType)
 	if err != nil {
 		if resp == nil {
 			return openai.ChatCompletionResponse{}, err
@@ -59,18 +60,23 @@ func (i *InstructorOpenAI) chat(ctx context.Context, request interface{}, schema
 		return i.chatJSON(ctx, &req, schema, true)
 	case ModeJSONSchema:
 		return i.chatJSONSchema(ctx, &req, schema)
+	case ModeMarkdownJSON:
+		return i.chatMarkdownJSON(ctx, &req, schema)
 	default:
-		return "", nil, fmt.Errorf("mode '%s' is not supported for %s", i.Mode(), i.Provider())
+		return "", nil, &UnsupportedModeError{Provider: i.Provider(), Mode: i.Mode()}
 	}
 }
 
 func (i *InstructorOpenAI) chatToolCall(ctx context.Context, request *openai.ChatCompletionRequest, schema *Schema, strict bool) (string, *openai.ChatCompletionResponse, error) {
 
 	request.Tools = createOpenAITools(schema, strict)
+	if i.ForceToolCall() && request.ToolChoice == nil {
+		request.ToolChoice = openAIToolChoice(schema)
+	}
 
 	resp, err := i.Client.CreateChatCompletion(ctx, *request)
 	if err != nil {
-		return "", nil, err
+		return "", nil, newProviderError(i.Provider(), err)
 	}
 
 	var toolCalls []openai.ToolCall
@@ -85,10 +91,15 @@ func (i *InstructorOpenAI) chatToolCall(ctx context.Context, request *openai.Cha
 	numTools := len(toolCalls)
 
 	if numTools < 1 {
-		return "", nilOpenaiRespWithUsage(&resp), errors.New("received no tool calls from model, expected at least 1")
+		text := ""
+		if len(resp.Choices) > 0 {
+			text = resp.Choices[0].Message.Content
+		}
+		return text, &resp, &DecodeError{Text: text, Err: errNoToolCalls}
 	}
 
-	if numTools == 1 {
+	// list responses are an array even when made of a single tool call
+	if numTools == 1 && schema.Type != "array" {
 		return toolCalls[0].Function.Arguments, &resp, nil
 	}
 
@@ -100,7 +111,7 @@ func (i *InstructorOpenAI) chatToolCall(ctx context.Context, request *openai.Cha
 		var jsonObj map[string]interface{}
 		err = json.Unmarshal([]byte(toolCall.Function.Arguments), &jsonObj)
 		if err != nil {
-			return "", nilOpenaiRespWithUsage(&resp), err
+			return toolCall.Function.Arguments, &resp, &DecodeError{Text: toolCall.Function.Arguments, Err: err}
 		}
 		jsonArray[i] = jsonObj
 	}
@@ -115,21 +126,29 @@ func (i *InstructorOpenAI) chatToolCall(ctx context.Context, request *openai.Cha
 
 func (i *InstructorOpenAI) chatJSON(ctx context.Context, request *openai.ChatCompletionRequest, schema *Schema, strict bool) (string, *openai.ChatCompletionResponse, error) {
 
-	structName := schema.NameFromRef()
+	structName := schema.responseName()
 
-	request.Messages = prepend(request.Messages, *createJSONMessage(schema))
+	schemaWrapper := ResponseFormatSchemaWrapper{
+		Type:        "object",
+		Required:    []string{structName},
+		Definitions: &schema.Schema.Definitions,
+		Properties: &jsonschema.Definitions{
+			structName: schema.responseDefinition(),
+		},
+		AdditionalProperties: false,
+	}
 
-	if strict {
-		schemaWrapper := ResponseFormatSchemaWrapper{
-			Type:        "object",
-			Required:    []string{structName},
-			Definitions: &schema.Schema.Definitions,
-			Properties: &jsonschema.Definitions{
-				structName: schema.Definitions[structName],
-			},
-			AdditionalProperties: false,
-		}
+	// JSON mode only generates objects, so lists are wrapped in one as well
+	wrapped := strict || schema.Type == "array"
+
+	if wrapped && !strict {
+		wrapperJSON, _ := json.MarshalIndent(schemaWrapper, "", "  ")
+		request.Messages = prepend(request.Messages, *createJSONMessage(string(wrapperJSON)))
+	} else {
+		request.Messages = prepend(request.Messages, *createJSONMessage(schema.String))
+	}
 
+	if strict {
 		schemaJSON, _ := json.Marshal(schemaWrapper)
 		schemaRaw := json.RawMessage(schemaJSON)
 
@@ -150,17 +169,16 @@ func (i *InstructorOpenAI) chatJSON(ctx context.Context, request *openai.ChatCom
 
 	resp, err := i.Client.CreateChatCompletion(ctx, *request)
 	if err != nil {
-		return "", nil, err
+		return "", nil, newProviderError(i.Provider(), err)
 	}
 
-	text := resp.Choices[0].Message.Content
-
-	if strict {
-		resMap := make(map[string]any)
-		_ = json.Unmarshal([]byte(text), &resMap)
+	text, err := openAIResponseText(&resp)
+	if err != nil {
+		return "", &resp, err
+	}
 
-		cleanedText, _ := json.Marshal(resMap[structName])
-		text = string(cleanedText)
+	if wrapped {
+		text = unwrapJSON(text, structName)
 	}
 
 	return text, &resp, nil
@@ -168,18 +186,109 @@ func (i *InstructorOpenAI) chatJSON(ctx context.Context, request *openai.ChatCom
 
 func (i *InstructorOpenAI) chatJSONSchema(ctx context.Context, request *openai.ChatCompletionRequest, schema *Schema) (string, *openai.ChatCompletionResponse, error) {
 
-	request.Messages = prepend(request.Messages, *createJSONMessage(schema))
+	request.Messages = prepend(request.Messages, *createJSONMessage(schema.String))
 
 	resp, err := i.Client.CreateChatCompletion(ctx, *request)
 	if err != nil {
-		return "", nil, err
+		return "", nil, newProviderError(i.Provider(), err)
 	}
 
-	text := resp.Choices[0].Message.Content
+	text, err := openAIResponseText(&resp)
+	if err != nil {
+		return "", &resp, err
+	}
 
 	return text, &resp, nil
 }
 
+func (i *InstructorOpenAI) chatMarkdownJSON(ctx context.Context, request *openai.ChatCompletionRequest, schema *Schema) (string, *openai.ChatCompletionResponse, error) {
+
+	request.Messages = prepend(request.Messages, openai.ChatCompletionMessage{
+		Role:    openai.ChatMessageRoleSystem,
+		Content: markdownJSONPrompt(schema),
+	})
+
+	resp, err := i.Client.CreateChatCompletion(ctx, *request)
+	if err != nil {
+		return "", nil, newProviderError(i.Provider(), err)
+	}
+
+	text, err := openAIResponseText(&resp)
+	if err != nil {
+		return "", &resp, err
+	}
+
+	return text, &resp, nil
+}
+
+// Returns the content of the first choice, a response without choices is a *DecodeError
+func openAIResponseText(resp *openai.ChatCompletionResponse) (string, error) {
+	if len(resp.Choices) == 0 {
+		return "", &DecodeError{Err: errors.New("received no choices from model, expected at least 1")}
+	}
+
+	return resp.Choices[0].Message.Content, nil
+}
+
+func (i *InstructorOpenAI) setModel(request interface{}, model string) interface{} {
+	req, ok := request.(openai.ChatCompletionRequest)
+	if !ok {
+		return request
+	}
+	req.Model = model
+	return req
+}
+
+func (i *InstructorOpenAI) validationRequest(request interface{}, prompt string) interface{} {
+	req, _ := request.(openai.ChatCompletionRequest)
+
+	return openai.ChatCompletionRequest{
+		Model: req.Model,
+		Messages: []openai.ChatCompletionMessage{
+			{
+				Role:    openai.ChatMessageRoleUser,
+				Content: prompt,
+			},
+		},
+	}
+}
+
+func (i *InstructorOpenAI) addReaskMessages(request interface{}, response interface{}, text string, err error) interface{} {
+	req, ok := request.(openai.ChatCompletionRequest)
+	if !ok {
+		return request
+	}
+
+	resp, ok := response.(*openai.ChatCompletionResponse)
+	if !ok || resp == nil || len(resp.Choices) == 0 {
+		return request
+	}
+
+	assistant := resp.Choices[0].Message
+
+	messages := appendCopy(req.Messages, assistant)
+
+	if len(assistant.ToolCalls) > 0 {
+		// every tool call must be answered by a tool message
+		for _, toolCall := range assistant.ToolCalls {
+			messages = append(messages, openai.ChatCompletionMessage{
+				Role:       openai.ChatMessageRoleTool,
+				ToolCallID: toolCall.ID,
+				Content:    reaskPrompt(err),
+			})
+		}
+	} else {
+		messages = append(messages, openai.ChatCompletionMessage{
+			Role:    openai.ChatMessageRoleUser,
+			Content: reaskPrompt(err),
+		})
+	}
+
+	req.Messages = messages
+
+	return req
+}
+
 func (i *InstructorOpenAI) emptyResponseWithUsageSum(usage *UsageSum) interface{} {
 	return &openai.ChatCompletionResponse{
 		Usage: openai.Usage{
@@ -216,7 +325,7 @@ func (i *InstructorOpenAI) addUsageSumToResponse(response interface{}, usage *Us
 
 func (i *InstructorOpenAI) countUsageFromResponse(response interface{}, usage *UsageSum) *UsageSum {
 	resp, ok := response.(*openai.ChatCompletionResponse)
-	if !ok {
+	if !ok || resp == nil {
 		return usage
 	}
 
@@ -227,14 +336,29 @@ func (i *InstructorOpenAI) countUsageFromResponse(response interface{}, usage *U
 	return usage
 }
 
-func createJSONMessage(schema *Schema) *openai.ChatCompletionMessage {
+// Returns the value of the key when the text is an object with it, the text
+// otherwise so that decoding it reports the error
+func unwrapJSON(text string, key string) string {
+	object := map[string]json.RawMessage{}
+	if err := json.Unmarshal([]byte(text), &object); err != nil {
+		return text
+	}
+
+	value, ok := object[key]
+	if !ok {
+		return text
+	}
+	return string(value)
+}
+
+func createJSONMessage(schemaJSON string) *openai.ChatCompletionMessage {
 	message := fmt.Sprintf(`
 Please respond with JSON in the following JSON schema:
 
 %s
 
 Make sure to return an instance of the JSON, not the schema itself
-`, schema.String)
+`, schemaJSON)
 
 	msg := &openai.ChatCompletionMessage{
 		Role:    openai.ChatMessageRoleSystem,
@@ -262,6 +386,18 @@ func createOpenAITools(schema *Schema, strict bool) []openai.Tool {
 	return tools
 }
 
+// Makes the model call the tool of the response, or any of the tools for
+// responses made of several tool calls
+func openAIToolChoice(schema *Schema) any {
+	if name := forcedToolName(schema); name != "" {
+		return openai.ToolChoice{
+			Type:     openai.ToolTypeFunction,
+			Function: openai.ToolFunction{Name: name},
+		}
+	}
+	return "required"
+}
+
 func nilOpenaiRespWithUsage(resp *openai.ChatCompletionResponse) *openai.ChatCompletionResponse {
 	if resp == nil {
 		return nil
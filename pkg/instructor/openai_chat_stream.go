@@ -13,17 +13,22 @@ func (i *InstructorOpenAI) CreateChatCompletionStream(
 	ctx context.Context,
 	request openai.ChatCompletionRequest,
 	responseType any,
-) (stream <-chan any, err error) {
-
-	stream, err = chatStreamHandler(i, ctx, request, responseType)
-	if err != nil {
-		return nil, err
-	}
+	opts ...Options,
+) (*Stream[any], error) {
+	return chatStreamHandler(i.withOptions(opts...), ctx, request, responseType)
+}
 
-	return stream, err
+// CreateChatCompletionPartialStream streams progressively filled copies of each response, see PartialResponse
+func (i *InstructorOpenAI) CreateChatCompletionPartialStream(
+	ctx context.Context,
+	request openai.ChatCompletionRequest,
+	responseType any,
+	opts ...Options,
+) (*Stream[*PartialResponse], error) {
+	return chatPartialStreamHandler(i.withOptions(opts...), ctx, request, responseType)
 }
 
-func (i *InstructorOpenAI) chatStream(ctx context.Context, request interface{}, schema *Schema) (<-chan string, error) {
+func (i *InstructorOpenAI) chatStream(ctx context.Context, request interface{}, schema *Schema) (<-chan streamChunk, error) {
 
 	req, ok := request.(openai.ChatCompletionRequest)
 	if !ok {
@@ -43,26 +48,39 @@ func (i *InstructorOpenAI) chatStream(ctx context.Context, request interface{},
 		return i.chatJSONStream(ctx, &req, schema)
 	case ModeJSONSchema:
 		return i.chatJSONSchemaStream(ctx, &req, schema)
+	case ModeMarkdownJSON:
+		return i.chatMarkdownJSONStream(ctx, &req, schema)
 	default:
-		return nil, fmt.Errorf("mode '%s' is not supported for %s", i.Mode(), i.Provider())
+		return nil, &UnsupportedModeError{Provider: i.Provider(), Mode: i.Mode()}
 	}
 }
 
-func (i *InstructorOpenAI) chatToolCallStream(ctx context.Context, request *openai.ChatCompletionRequest, schema *Schema, strict bool) (<-chan string, error) {
+func (i *InstructorOpenAI) chatToolCallStream(ctx context.Context, request *openai.ChatCompletionRequest, schema *Schema, strict bool) (<-chan streamChunk, error) {
 	request.Tools = createOpenAITools(schema, strict)
-	return i.createStream(ctx, request)
+	if i.ForceToolCall() && request.ToolChoice == nil {
+		request.ToolChoice = openAIToolChoice(schema)
+	}
+	return i.createStream(ctx, request, schema)
 }
 
-func (i *InstructorOpenAI) chatJSONStream(ctx context.Context, request *openai.ChatCompletionRequest, schema *Schema) (<-chan string, error) {
+func (i *InstructorOpenAI) chatJSONStream(ctx context.Context, request *openai.ChatCompletionRequest, schema *Schema) (<-chan streamChunk, error) {
 	request.Messages = prepend(request.Messages, *createJSONMessageStream(schema))
 	// Set JSON mode
 	request.ResponseFormat = &openai.ChatCompletionResponseFormat{Type: openai.ChatCompletionResponseFormatTypeJSONObject}
-	return i.createStream(ctx, request)
+	return i.createStream(ctx, request, schema)
 }
 
-func (i *InstructorOpenAI) chatJSONSchemaStream(ctx context.Context, request *openai.ChatCompletionRequest, schema *Schema) (<-chan string, error) {
+func (i *InstructorOpenAI) chatJSONSchemaStream(ctx context.Context, request *openai.ChatCompletionRequest, schema *Schema) (<-chan streamChunk, error) {
 	request.Messages = prepend(request.Messages, *createJSONMessageStream(schema))
-	return i.createStream(ctx, request)
+	return i.createStream(ctx, request, schema)
+}
+
+func (i *InstructorOpenAI) chatMarkdownJSONStream(ctx context.Context, request *openai.ChatCompletionRequest, schema *Schema) (<-chan streamChunk, error) {
+	request.Messages = prepend(request.Messages, openai.ChatCompletionMessage{
+		Role:    openai.ChatMessageRoleSystem,
+		Content: markdownJSONPrompt(schema),
+	})
+	return i.createStream(ctx, request, schema)
 }
 
 func createJSONMessageStream(schema *Schema) *openai.ChatCompletionMessage {
@@ -82,27 +100,69 @@ Make sure to return an array with the elements an instance of the JSON, not the
 	return msg
 }
 
-func (i *InstructorOpenAI) createStream(ctx context.Context, request *openai.ChatCompletionRequest) (<-chan string, error) {
+func (i *InstructorOpenAI) createStream(ctx context.Context, request *openai.ChatCompletionRequest, schema *Schema) (<-chan streamChunk, error) {
+	if request.StreamOptions == nil {
+		// usage is sent in a last chunk without choices
+		request.StreamOptions = &openai.StreamOptions{IncludeUsage: true}
+	}
+
 	stream, err := i.Client.CreateChatCompletionStream(ctx, *request)
 	if err != nil {
-		return nil, err
+		return nil, newProviderError(i.Provider(), err)
 	}
 
-	ch := make(chan string)
+	ch := make(chan streamChunk)
 
 	go func() {
 		defer stream.Close()
 		defer close(ch)
+
+		toolCalls := newToolCallStream(schema.streamWrapper)
+
 		for {
 			response, err := stream.Recv()
 			if errors.Is(err, io.EOF) {
+				sendChunk(ctx, ch, streamChunk{Text: toolCalls.end()})
 				return
 			}
 			if err != nil {
+				sendChunk(ctx, ch, streamChunk{Err: newProviderError(i.Provider(), err)})
+				return
+			}
+			if response.Usage != nil {
+				usage := &UsageSum{
+					InputTokens:  response.Usage.PromptTokens,
+					OutputTokens: response.Usage.CompletionTokens,
+					TotalTokens:  response.Usage.TotalTokens,
+				}
+				if !sendChunk(ctx, ch, streamChunk{Usage: usage}) {
+					return
+				}
+			}
+			if len(response.Choices) == 0 {
+				continue
+			}
+
+			delta := response.Choices[0].Delta
+
+			text := delta.Content
+			if len(delta.ToolCalls) > 0 {
+				text = ""
+				for _, toolCall := range delta.ToolCalls {
+					index := 0
+					if toolCall.Index != nil {
+						index = *toolCall.Index
+					}
+					text += toolCalls.add(index, toolCall.Function.Arguments)
+				}
+			} else if toolCalls.started() {
+				// the elements are the tool calls, ignore any text around them
+				text = ""
+			}
+
+			if !sendChunk(ctx, ch, streamChunk{Text: text}) {
 				return
 			}
-			text := response.Choices[0].Delta.Content
-			ch <- text
 		}
 	}()
 	return ch, nil
@@ -0,0 +1,322 @@
+package instructor
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+
+	openai "github.com/sashabaranov/go-openai"
+)
+
+type openAITestPerson struct {
+	Name string `json:"name"`
+}
+
+// Parts of the chat completion requests checked by the tests
+type openAITestRequest struct {
+	Messages       []openai.ChatCompletionMessage `json:"messages"`
+	ResponseFormat struct {
+		Type       string `json:"type"`
+		JSONSchema struct {
+			Name string `json:"name"`
+		} `json:"json_schema"`
+	} `json:"response_format"`
+}
+
+// Client of a server answering every request with the next of the bodies,
+// the requests it received are appended to requests
+func newOpenAITestClient(t *testing.T, requests *[]openAITestRequest, bodies ...string) *openai.Client {
+	t.Helper()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var request openAITestRequest
+		if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+			t.Errorf("got error %v decoding the request", err)
+		}
+		if requests != nil {
+			*requests = append(*requests, request)
+		}
+		if len(bodies) == 0 {
+			t.Error("got more requests than responses")
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprint(w, bodies[0])
+		bodies = bodies[1:]
+	}))
+	t.Cleanup(server.Close)
+
+	config := openai.DefaultConfig("test")
+	config.BaseURL = server.URL + "/v1"
+	return openai.NewClientWithConfig(config)
+}
+
+// Body of a chat completion answering with the content
+func openAITestContent(content string) string {
+	body, _ := json.Marshal(openai.ChatCompletionResponse{
+		Choices: []openai.ChatCompletionChoice{{
+			Message: openai.ChatCompletionMessage{Role: openai.ChatMessageRoleAssistant, Content: content},
+		}},
+	})
+	return string(body)
+}
+
+func TestOpenAIJSONList(t *testing.T) {
+	tests := []struct {
+		name string
+		mode Mode
+	}{
+		{name: "JSON", mode: ModeJSON},
+		{name: "strict JSON", mode: ModeJSONStrict},
+	}
+
+	const wrapper = "openAITestPersonList"
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			requests := []openAITestRequest{}
+			client := FromOpenAI(
+				newOpenAITestClient(t, &requests, openAITestContent(`{"openAITestPersonList": [{"name": "Ann"}, {"name": "Bo"}]}`)),
+				WithMode(tt.mode),
+				WithPartialAcceptance(PartialAcceptance{MaxDropped: 1}),
+			)
+
+			var people []openAITestPerson
+			_, err := client.CreateChatCompletion(context.Background(), openai.ChatCompletionRequest{
+				Model:    "test",
+				Messages: []openai.ChatCompletionMessage{{Role: openai.ChatMessageRoleUser, Content: "people"}},
+			}, &people)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if want := []openAITestPerson{{Name: "Ann"}, {Name: "Bo"}}; !reflect.DeepEqual(people, want) {
+				t.Errorf("got %v, want %v", people, want)
+			}
+			if tt.mode == ModeJSONStrict {
+				if got := requests[0].ResponseFormat.JSONSchema.Name; got != wrapper {
+					t.Errorf("got JSON schema name %q, want %q", got, wrapper)
+				}
+			} else if prompt := requests[0].Messages[0].Content; !strings.Contains(prompt, `"`+wrapper+`"`) {
+				t.Errorf("got prompt %q, want the list wrapped in an object", prompt)
+			}
+		})
+	}
+}
+
+func TestOpenAIModelReport(t *testing.T) {
+	tests := []struct {
+		name    string
+		bodies  []string
+		want    string
+		wantErr bool
+	}{
+		{
+			name:   "first model",
+			bodies: []string{openAITestContent(`{"name": "Ann"}`)},
+			want:   "small",
+		},
+		{
+			name:   "escalated",
+			bodies: []string{openAITestContent(`not JSON`), openAITestContent(`{"name": "Ann"}`)},
+			want:   "large",
+		},
+		{
+			name:    "failed",
+			bodies:  []string{openAITestContent(`not JSON`), openAITestContent(`not JSON`)},
+			want:    "large",
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client := FromOpenAI(
+				newOpenAITestClient(t, nil, tt.bodies...),
+				WithMode(ModeJSON),
+				WithMaxRetries(1),
+				WithModelEscalation(1, "small", "large"),
+			)
+
+			var model string
+			var person openAITestPerson
+			_, err := client.CreateChatCompletion(context.Background(), openai.ChatCompletionRequest{
+				Model:    "test",
+				Messages: []openai.ChatCompletionMessage{{Role: openai.ChatMessageRoleUser, Content: "person"}},
+			}, &person, WithModelReport(&model))
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("got error %v, want error %v", err, tt.wantErr)
+			}
+
+			if model != tt.want {
+				t.Errorf("got model %q, want %q", model, tt.want)
+			}
+		})
+	}
+}
+
+func TestOpenAINoChoices(t *testing.T) {
+	tests := []struct {
+		name string
+		mode Mode
+	}{
+		{name: "JSON", mode: ModeJSON},
+		{name: "JSON schema", mode: ModeJSONSchema},
+		{name: "markdown JSON", mode: ModeMarkdownJSON},
+		{name: "tool call", mode: ModeToolCall},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client := FromOpenAI(
+				newOpenAITestClient(t, nil, `{"choices": []}`),
+				WithMode(tt.mode),
+				WithMaxRetries(0),
+			)
+
+			var person openAITestPerson
+			_, err := client.CreateChatCompletion(context.Background(), openai.ChatCompletionRequest{
+				Model:    "test",
+				Messages: []openai.ChatCompletionMessage{{Role: openai.ChatMessageRoleUser, Content: "person"}},
+			}, &person)
+
+			var maxRetriesErr *MaxRetriesExceededError
+			if !errors.As(err, &maxRetriesErr) {
+				t.Fatalf("got error %v, want a *MaxRetriesExceededError", err)
+			}
+			var decodeErr *DecodeError
+			if !errors.As(maxRetriesErr.Attempts[0].Err, &decodeErr) {
+				t.Errorf("got attempt error %v, want a *DecodeError", maxRetriesErr.Attempts[0].Err)
+			}
+		})
+	}
+}
+
+func TestOpenAIAddReaskMessages(t *testing.T) {
+	reaskErr := &DecodeError{Err: errors.New("unexpected end of JSON input")}
+	user := openai.ChatCompletionMessage{Role: openai.ChatMessageRoleUser, Content: "person"}
+
+	toolCalls := []openai.ToolCall{
+		{ID: "call_1", Type: openai.ToolTypeFunction, Function: openai.FunctionCall{Name: "Person", Arguments: `{"name":`}},
+		{ID: "call_2", Type: openai.ToolTypeFunction, Function: openai.FunctionCall{Name: "Person", Arguments: `{}`}},
+	}
+
+	tests := []struct {
+		name     string
+		response *openai.ChatCompletionResponse
+		want     []openai.ChatCompletionMessage
+	}{
+		{
+			name: "content",
+			response: &openai.ChatCompletionResponse{Choices: []openai.ChatCompletionChoice{{
+				Message: openai.ChatCompletionMessage{Role: openai.ChatMessageRoleAssistant, Content: `{"name":`},
+			}}},
+			want: []openai.ChatCompletionMessage{
+				user,
+				{Role: openai.ChatMessageRoleAssistant, Content: `{"name":`},
+				{Role: openai.ChatMessageRoleUser, Content: reaskPrompt(reaskErr)},
+			},
+		},
+		{
+			name: "tool calls",
+			response: &openai.ChatCompletionResponse{Choices: []openai.ChatCompletionChoice{{
+				Message: openai.ChatCompletionMessage{Role: openai.ChatMessageRoleAssistant, ToolCalls: toolCalls},
+			}}},
+			want: []openai.ChatCompletionMessage{
+				user,
+				{Role: openai.ChatMessageRoleAssistant, ToolCalls: toolCalls},
+				{Role: openai.ChatMessageRoleTool, ToolCallID: "call_1", Content: reaskPrompt(reaskErr)},
+				{Role: openai.ChatMessageRoleTool, ToolCallID: "call_2", Content: reaskPrompt(reaskErr)},
+			},
+		},
+		{
+			name:     "no choices",
+			response: &openai.ChatCompletionResponse{},
+			want:     []openai.ChatCompletionMessage{user},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// spare capacity, appending must still copy
+			messages := make([]openai.ChatCompletionMessage, 1, 4)
+			messages[0] = user
+			request := openai.ChatCompletionRequest{Model: "test", Messages: messages}
+
+			client := FromOpenAI(nil)
+			got := client.addReaskMessages(request, tt.response, `{"name":`, reaskErr).(openai.ChatCompletionRequest)
+
+			if !reflect.DeepEqual(got.Messages, tt.want) {
+				t.Errorf("got %+v, want %+v", got.Messages, tt.want)
+			}
+			if extra := messages[:2][1]; !reflect.DeepEqual(extra, openai.ChatCompletionMessage{}) {
+				t.Errorf("got %+v written into the caller's messages", extra)
+			}
+		})
+	}
+}
+
+func TestOpenAIToolChoice(t *testing.T) {
+	tests := []struct {
+		name         string
+		responseType any
+		want         any
+	}{
+		{
+			name:         "struct",
+			responseType: openAITestPerson{},
+			want:         openai.ToolChoice{Type: openai.ToolTypeFunction, Function: openai.ToolFunction{Name: "openAITestPerson"}},
+		},
+		{
+			name:         "slice",
+			responseType: []openAITestPerson{},
+			want:         "required",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			schema, err := NewSchema(reflect.TypeOf(tt.responseType))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if got := openAIToolChoice(schema); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestOpenAIToolCallList(t *testing.T) {
+	body, _ := json.Marshal(openai.ChatCompletionResponse{
+		Choices: []openai.ChatCompletionChoice{{
+			Message: openai.ChatCompletionMessage{Role: openai.ChatMessageRoleAssistant, ToolCalls: []openai.ToolCall{{
+				ID:       "call_1",
+				Type:     openai.ToolTypeFunction,
+				Function: openai.FunctionCall{Name: "openAITestPerson", Arguments: `{"name": "Ann"}`},
+			}}},
+		}},
+	})
+	client := FromOpenAI(newOpenAITestClient(t, nil, string(body)), WithMode(ModeToolCall), WithMaxRetries(0))
+
+	var people []openAITestPerson
+	_, err := client.CreateChatCompletion(context.Background(), openai.ChatCompletionRequest{
+		Model:    "test",
+		Messages: []openai.ChatCompletionMessage{{Role: openai.ChatMessageRoleUser, Content: "people"}},
+	}, &people)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if want := []openAITestPerson{{Name: "Ann"}}; !reflect.DeepEqual(people, want) {
+		t.Errorf("got %v, want %v", people, want)
+	}
+}
@@ -6,39 +6,27 @@ import (
 
 type InstructorOpenAI struct {
 	*openai.Client
-
-	provider   Provider
-	mode       Mode
-	maxRetries int
-	validate   bool
+	clientOptions
 }
 
 var _ Instructor = &InstructorOpenAI{}
 
 func FromOpenAI(client *openai.Client, opts ...Options) *InstructorOpenAI {
-
-	options := mergeOptions(opts...)
-
-	i := &InstructorOpenAI{
-		Client: client,
-
-		provider:   ProviderOpenAI,
-		mode:       *options.Mode,
-		maxRetries: *options.MaxRetries,
-		validate:   *options.validate,
+	return &InstructorOpenAI{
+		Client:        client,
+		clientOptions: newClientOptions(ProviderOpenAI, opts...),
 	}
-	return i
 }
 
-func (i *InstructorOpenAI) Provider() Provider {
-	return i.provider
-}
-func (i *InstructorOpenAI) Mode() Mode {
-	return i.mode
-}
-func (i *InstructorOpenAI) MaxRetries() int {
-	return i.maxRetries
+// Copy of the client with per-call options merged over its options
+func (i *InstructorOpenAI) withOptions(opts ...Options) *InstructorOpenAI {
+	if len(opts) == 0 {
+		return i
+	}
+	return &InstructorOpenAI{Client: i.Client, clientOptions: i.with(opts...)}
 }
-func (i *InstructorOpenAI) Validate() bool {
-	return i.validate
+
+// Copy running the secondary calls of LLM validation, see Options.forValidation
+func (i *InstructorOpenAI) validationInstructor() Instructor {
+	return &InstructorOpenAI{Client: i.Client, clientOptions: i.forValidation()}
 }
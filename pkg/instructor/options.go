@@ -1,21 +1,40 @@
 package instructor
 
+import (
+	"reflect"
+
+	"github.com/go-playground/validator/v10"
+)
+
 const (
 	DefaultMaxRetries = 3
 	DefaultValidator  = false
+	// tool call modes make the model call the tools
+	DefaultForceToolCall = true
 )
 
 type Options struct {
-	Mode       *Mode
-	MaxRetries *int
-	validate   *bool
+	Mode        *Mode
+	MaxRetries  *int
+	RetryPolicy *RetryPolicy
+	// Drop invalid elements of slice responses, nil retries the whole response
+	PartialAcceptance *PartialAcceptance
+	// Switch to stronger models after failed attempts, nil keeps the request's model
+	ModelEscalation *ModelEscalation
+	validate        *bool
+	validator       *validator.Validate
+	llmRules        map[reflect.Type][]LLMRule
+	forceToolCall   *bool
+	modelReport     *string
 	// Provider specific options:
 }
 
 var defaultOptions = Options{
-	Mode:       toPtr(ModeDefault),
-	MaxRetries: toPtr(DefaultMaxRetries),
-	validate:   toPtr(DefaultValidator),
+	Mode:          toPtr(ModeDefault),
+	MaxRetries:    toPtr(DefaultMaxRetries),
+	RetryPolicy:   toPtr(noRetryPolicy),
+	validate:      toPtr(DefaultValidator),
+	forceToolCall: toPtr(DefaultForceToolCall),
 }
 
 func WithMode(mode Mode) Options {
@@ -26,10 +45,69 @@ func WithMaxRetries(maxRetries int) Options {
 	return Options{MaxRetries: toPtr(maxRetries)}
 }
 
+// WithRetryPolicy retries failed provider requests (rate limits, server errors),
+// ex: WithRetryPolicy(DefaultRetryPolicy)
+func WithRetryPolicy(policy RetryPolicy) Options {
+	return Options{RetryPolicy: toPtr(policy)}
+}
+
+// WithPartialAcceptance drops slice elements that fail to decode or validate
+// and returns the rest, retrying only when more than maxDropped are dropped
+func WithPartialAcceptance(policy PartialAcceptance) Options {
+	return Options{PartialAcceptance: toPtr(policy)}
+}
+
+// WithModelEscalation starts with the first model and moves to the next one
+// after attemptsPerModel failed attempts, ex:
+//
+//	WithModelEscalation(2, openai.GPT4oMini, openai.GPT4o)
+func WithModelEscalation(attemptsPerModel int, models ...string) Options {
+	return Options{ModelEscalation: &ModelEscalation{Models: models, AttemptsPerModel: attemptsPerModel}}
+}
+
+// WithValidation checks responses against their `validate` struct tags and
+// runs the Validate() method of those implementing Validatable, the model is
+// reasked on failure
 func WithValidation() Options {
 	return Options{validate: toPtr(true)}
 }
 
+// WithValidator enables validation using the given validator instance,
+// so custom tags and struct level validations registered on it are applied
+func WithValidator(v *validator.Validate) Options {
+	return Options{validate: toPtr(true), validator: v}
+}
+
+// WithLLMRule checks a field of the response type against a natural-language
+// rule with a secondary call to the model, ex:
+//
+//	WithLLMRule(Review{}, "reason", "must reference the product")
+func WithLLMRule(responseType any, field string, rule string) Options {
+	t := indirectType(reflect.TypeOf(responseType))
+	return Options{llmRules: map[reflect.Type][]LLMRule{
+		t: {{Field: field, Rule: rule}},
+	}}
+}
+
+// WithAutoToolChoice lets the model answer without calling the tools in tool
+// call modes. By default OpenAI and Anthropic are made to call them with
+// tool_choice; the Cohere chat API has no tool_choice, so forcing is only
+// best-effort there: the preamble instructs the model to call the tools.
+func WithAutoToolChoice() Options {
+	return Options{forceToolCall: toPtr(false)}
+}
+
+// WithModelReport stores the model that produced the response under model
+// escalation in *model, the same way for every provider. On failure it holds
+// the model of the last attempt. It is left untouched without escalation.
+// Pass it per call, ex:
+//
+//	var model string
+//	client.CreateChatCompletion(ctx, request, &resp, WithModelReport(&model))
+func WithModelReport(model *string) Options {
+	return Options{modelReport: model}
+}
+
 func mergeOption(old, new Options) Options {
 	if new.Mode != nil {
 		old.Mode = new.Mode
@@ -37,19 +115,69 @@ func mergeOption(old, new Options) Options {
 	if new.MaxRetries != nil {
 		old.MaxRetries = new.MaxRetries
 	}
+	if new.RetryPolicy != nil {
+		old.RetryPolicy = new.RetryPolicy
+	}
+	if new.PartialAcceptance != nil {
+		old.PartialAcceptance = new.PartialAcceptance
+	}
+	if new.ModelEscalation != nil {
+		old.ModelEscalation = new.ModelEscalation
+	}
 	if new.validate != nil {
 		old.validate = new.validate
 	}
+	if new.validator != nil {
+		old.validator = new.validator
+	}
+	if new.forceToolCall != nil {
+		old.forceToolCall = new.forceToolCall
+	}
+	if new.modelReport != nil {
+		old.modelReport = new.modelReport
+	}
+	if new.llmRules != nil {
+		// copy so merging never modifies the options it was given
+		llmRules := make(map[reflect.Type][]LLMRule, len(old.llmRules)+len(new.llmRules))
+		for t, rules := range old.llmRules {
+			llmRules[t] = rules
+		}
+		for t, rules := range new.llmRules {
+			llmRules[t] = append(append([]LLMRule{}, llmRules[t]...), rules...)
+		}
+		old.llmRules = llmRules
+	}
 
 	return old
 }
 
 func mergeOptions(opts ...Options) Options {
-	options := defaultOptions
+	return mergeOptionsOver(defaultOptions, opts...)
+}
 
+func mergeOptionsOver(options Options, opts ...Options) Options {
 	for _, opt := range opts {
 		options = mergeOption(options, opt)
 	}
 
 	return options
 }
+
+// Options of the secondary calls of LLM validation: they keep the model of
+// the validated request, so escalation (and its callback) and partial
+// acceptance are left out, as is the model report
+func (o Options) forValidation() Options {
+	o.ModelEscalation = nil
+	o.PartialAcceptance = nil
+	o.modelReport = nil
+	return o
+}
+
+// Each client gets its own validator unless one is provided, so
+// registrations never leak between clients
+func (o Options) validatorOrNew() *validator.Validate {
+	if o.validator != nil {
+		return o.validator
+	}
+	return validator.New()
+}
@@ -0,0 +1,97 @@
+package instructor
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+)
+
+// PartialAcceptance keeps the valid elements of slice responses and drops the
+// ones that fail to decode or validate, instead of retrying the whole response.
+type PartialAcceptance struct {
+	// Most elements that may be dropped, the response is retried above it
+	MaxDropped int
+	// Called with the dropped elements of an accepted response, optional
+	OnDropped func(dropped []DroppedElement)
+}
+
+// DroppedElement is an element removed from a slice response under
+// PartialAcceptance, or skipped by a Stream
+type DroppedElement struct {
+	// Position of the element in the model output
+	Index int
+	// Raw JSON of the element
+	Text string
+	// Why the element was dropped, a *DecodeError or *ValidationError
+	Err error
+}
+
+// Decodes a JSON array into the slice response element by element, keeping
+// only the elements that decode and validate
+func decodePartial(i Instructor, jsonText string, response any) ([]DroppedElement, error) {
+	var rawElements []json.RawMessage
+
+	err := json.Unmarshal([]byte(jsonText), &rawElements)
+	if err != nil {
+		return nil, &DecodeError{Text: jsonText, Err: err}
+	}
+
+	slice := reflect.ValueOf(response)
+	for slice.Kind() == reflect.Pointer || slice.Kind() == reflect.Interface {
+		slice = slice.Elem()
+	}
+	if slice.Kind() != reflect.Slice || !slice.CanSet() {
+		return nil, fmt.Errorf("partial acceptance requires a pointer to a slice response, got %T", response)
+	}
+
+	valid := reflect.MakeSlice(slice.Type(), 0, len(rawElements))
+	dropped := []DroppedElement{}
+
+	for idx, rawElement := range rawElements {
+		element := reflect.New(slice.Type().Elem())
+		path := indexPath("", idx)
+
+		if err := json.Unmarshal(rawElement, element.Interface()); err != nil {
+			dropped = append(dropped, DroppedElement{
+				Index: idx,
+				Text:  string(rawElement),
+				Err: &DecodeError{Text: string(rawElement), Err: &FieldError{
+					Path:    path,
+					Message: fmt.Sprintf("%s could not be decoded: %v", path, err),
+					Err:     err,
+				}},
+			})
+			continue
+		}
+
+		var errs []error
+		if i.Validate() {
+			errs = append(errs, validateStructAt(i.Validator(), element, path))
+			errs = append(errs, validateValue(element, path))
+		}
+
+		if err := errors.Join(errs...); err != nil {
+			dropped = append(dropped, DroppedElement{
+				Index: idx,
+				Text:  string(rawElement),
+				Err:   &ValidationError{Err: err},
+			})
+			continue
+		}
+
+		valid = reflect.Append(valid, element.Elem())
+	}
+
+	slice.Set(valid)
+
+	return dropped, nil
+}
+
+func droppedErrors(dropped []DroppedElement) error {
+	errs := make([]error, 0, len(dropped))
+	for _, d := range dropped {
+		errs = append(errs, d.Err)
+	}
+	return errors.Join(errs...)
+}
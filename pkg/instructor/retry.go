@@ -0,0 +1,259 @@
+package instructor
+
+import (
+	"context"
+	"errors"
+	"math"
+	"math/rand"
+	"net"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	cohereCore "github.com/cohere-ai/cohere-go/v2/core"
+	anthropic "github.com/liushuangls/go-anthropic/v2"
+	openai "github.com/sashabaranov/go-openai"
+)
+
+// RetryPolicy controls how failed provider requests (rate limits, server
+// errors, timeouts) are retried. It is independent of MaxRetries, which only
+// covers responses that fail to decode or validate.
+type RetryPolicy struct {
+	// Number of times a failed provider request is retried, 0 disables retrying
+	MaxRetries int
+	// Wait before the first retry
+	InitialBackoff time.Duration
+	// Upper bound of the wait between retries
+	MaxBackoff time.Duration
+	// Upper bound of the wait requested by the provider with Retry-After,
+	// 0 caps it at MaxBackoff
+	MaxRetryAfter time.Duration
+	// Factor the wait grows by after every retry
+	Multiplier float64
+	// Fraction of the wait that is randomized, ex: 0.2 waits between 80% and 120%
+	Jitter float64
+	// Reports whether an error should be retried, defaults to IsRetryableError
+	Retryable func(err error) bool
+}
+
+var DefaultRetryPolicy = RetryPolicy{
+	MaxRetries:     3,
+	InitialBackoff: 500 * time.Millisecond,
+	MaxBackoff:     30 * time.Second,
+	MaxRetryAfter:  time.Minute,
+	Multiplier:     2,
+	Jitter:         0.2,
+}
+
+var noRetryPolicy = RetryPolicy{}
+
+func (p RetryPolicy) retryable(err error) bool {
+	if p.Retryable != nil {
+		return p.Retryable(err)
+	}
+	return IsRetryableError(err)
+}
+
+func (p RetryPolicy) backoff(retry int) time.Duration {
+	wait := float64(p.InitialBackoff) * math.Pow(max(p.Multiplier, 1), float64(retry))
+
+	if p.MaxBackoff > 0 {
+		wait = min(wait, float64(p.MaxBackoff))
+	}
+
+	if p.Jitter > 0 {
+		wait += wait * p.Jitter * (2*rand.Float64() - 1)
+	}
+
+	return time.Duration(max(wait, 0))
+}
+
+// Caps the wait requested by the provider, a server asking for hours would
+// otherwise block the call for as long
+func (p RetryPolicy) retryAfter(wait time.Duration) time.Duration {
+	limit := p.MaxRetryAfter
+	if limit == 0 {
+		limit = p.MaxBackoff
+	}
+	if limit > 0 {
+		return min(wait, limit)
+	}
+	return wait
+}
+
+// IsRetryableError reports whether an error returned by the OpenAI, Anthropic
+// or Cohere client is transient: rate limits, overloaded or failing servers
+// and network timeouts.
+func IsRetryableError(err error) bool {
+	if err == nil || errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return false
+	}
+
+	if statusCode, ok := statusCodeFromError(err); ok {
+		return isRetryableStatusCode(statusCode)
+	}
+
+	var anthropicErr *anthropic.APIError
+	if errors.As(err, &anthropicErr) {
+		return anthropicErr.IsRateLimitErr() || anthropicErr.IsOverloadedErr() || anthropicErr.IsApiErr()
+	}
+
+	var netErr net.Error
+	if errors.As(err, &netErr) {
+		return netErr.Timeout()
+	}
+
+	return false
+}
+
+func isRetryableStatusCode(statusCode int) bool {
+	switch statusCode {
+	case http.StatusRequestTimeout,
+		http.StatusConflict,
+		http.StatusTooManyRequests,
+		http.StatusInternalServerError,
+		http.StatusBadGateway,
+		http.StatusServiceUnavailable,
+		http.StatusGatewayTimeout,
+		529: // Anthropic: overloaded
+		return true
+	default:
+		return false
+	}
+}
+
+func statusCodeFromError(err error) (int, bool) {
+	var openaiAPIErr *openai.APIError
+	if errors.As(err, &openaiAPIErr) && openaiAPIErr.HTTPStatusCode > 0 {
+		return openaiAPIErr.HTTPStatusCode, true
+	}
+
+	var openaiReqErr *openai.RequestError
+	if errors.As(err, &openaiReqErr) && openaiReqErr.HTTPStatusCode > 0 {
+		return openaiReqErr.HTTPStatusCode, true
+	}
+
+	var anthropicReqErr *anthropic.RequestError
+	if errors.As(err, &anthropicReqErr) && anthropicReqErr.StatusCode > 0 {
+		return anthropicReqErr.StatusCode, true
+	}
+
+	var cohereErr *cohereCore.APIError
+	if errors.As(err, &cohereErr) && cohereErr.StatusCode > 0 {
+		return cohereErr.StatusCode, true
+	}
+
+	return 0, false
+}
+
+// Calls the provider, retrying transient failures according to the policy
+func retryWithPolicy(ctx context.Context, policy RetryPolicy, call func(ctx context.Context) error) error {
+	for retry := 0; ; retry++ {
+
+		hint := &retryAfterHint{}
+
+		err := call(context.WithValue(ctx, retryAfterHintKey{}, hint))
+		if err == nil || retry >= policy.MaxRetries || !policy.retryable(err) {
+			return err
+		}
+
+		wait := policy.backoff(retry)
+		if retryAfter, ok := hint.get(); ok {
+			wait = policy.retryAfter(retryAfter)
+		}
+
+		timer := time.NewTimer(wait)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return errors.Join(err, ctx.Err())
+		case <-timer.C:
+		}
+	}
+}
+
+// RetryAfterTransport is an http.RoundTripper that makes the Retry-After
+// header of failed responses visible to the RetryPolicy, since the provider
+// clients drop response headers from their errors. Install it in the HTTP
+// client given to the provider client, ex:
+//
+//	config := openai.DefaultConfig(apiKey)
+//	config.HTTPClient = &http.Client{Transport: &instructor.RetryAfterTransport{}}
+type RetryAfterTransport struct {
+	// Transport used to send requests, defaults to http.DefaultTransport
+	Base http.RoundTripper
+}
+
+func (t *RetryAfterTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	base := t.Base
+	if base == nil {
+		base = http.DefaultTransport
+	}
+
+	resp, err := base.RoundTrip(req)
+	if err != nil || resp.StatusCode < http.StatusBadRequest {
+		return resp, err
+	}
+
+	if hint, ok := req.Context().Value(retryAfterHintKey{}).(*retryAfterHint); ok {
+		if retryAfter, ok := parseRetryAfter(resp.Header, time.Now()); ok {
+			hint.set(retryAfter)
+		}
+	}
+
+	return resp, err
+}
+
+type retryAfterHintKey struct{}
+
+type retryAfterHint struct {
+	mu    sync.Mutex
+	wait  time.Duration
+	isSet bool
+}
+
+func (h *retryAfterHint) set(wait time.Duration) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.wait = wait
+	h.isSet = true
+}
+
+func (h *retryAfterHint) get() (time.Duration, bool) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return h.wait, h.isSet
+}
+
+// Reads the wait requested by the provider from the "retry-after-ms" (OpenAI)
+// or the standard "Retry-After" header, in seconds or as an HTTP date
+func parseRetryAfter(header http.Header, now time.Time) (time.Duration, bool) {
+	if ms, err := strconv.ParseFloat(header.Get("Retry-After-Ms"), 64); err == nil && ms >= 0 {
+		return durationOf(ms, time.Millisecond), true
+	}
+
+	value := header.Get("Retry-After")
+	if value == "" {
+		return 0, false
+	}
+
+	if seconds, err := strconv.ParseFloat(value, 64); err == nil && seconds >= 0 {
+		return durationOf(seconds, time.Second), true
+	}
+
+	if date, err := http.ParseTime(value); err == nil {
+		return max(date.Sub(now), 0), true
+	}
+
+	return 0, false
+}
+
+// Converts a non-negative count of units, saturating instead of overflowing
+// on huge values, ex: "Retry-After: 1e30"
+func durationOf(count float64, unit time.Duration) time.Duration {
+	if count*float64(unit) >= math.MaxInt64 {
+		return math.MaxInt64
+	}
+	return time.Duration(count * float64(unit))
+}
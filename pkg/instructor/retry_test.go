@@ -0,0 +1,228 @@
+package instructor
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"net"
+	"net/http"
+	"testing"
+	"time"
+
+	cohereCore "github.com/cohere-ai/cohere-go/v2/core"
+	anthropic "github.com/liushuangls/go-anthropic/v2"
+	openai "github.com/sashabaranov/go-openai"
+)
+
+func TestParseRetryAfter(t *testing.T) {
+	now := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name   string
+		header map[string]string
+		want   time.Duration
+		wantOK bool
+	}{
+		{
+			name:   "no header",
+			header: map[string]string{},
+		},
+		{
+			name:   "milliseconds",
+			header: map[string]string{"Retry-After-Ms": "1500"},
+			want:   1500 * time.Millisecond,
+			wantOK: true,
+		},
+		{
+			name:   "milliseconds before seconds",
+			header: map[string]string{"Retry-After-Ms": "250", "Retry-After": "3"},
+			want:   250 * time.Millisecond,
+			wantOK: true,
+		},
+		{
+			name:   "seconds",
+			header: map[string]string{"Retry-After": "3"},
+			want:   3 * time.Second,
+			wantOK: true,
+		},
+		{
+			name:   "fractional seconds",
+			header: map[string]string{"Retry-After": "0.5"},
+			want:   500 * time.Millisecond,
+			wantOK: true,
+		},
+		{
+			name:   "HTTP date",
+			header: map[string]string{"Retry-After": now.Add(90 * time.Second).Format(http.TimeFormat)},
+			want:   90 * time.Second,
+			wantOK: true,
+		},
+		{
+			name:   "HTTP date in the past",
+			header: map[string]string{"Retry-After": now.Add(-time.Minute).Format(http.TimeFormat)},
+			want:   0,
+			wantOK: true,
+		},
+		{
+			name:   "negative milliseconds fall back to seconds",
+			header: map[string]string{"Retry-After-Ms": "-100", "Retry-After": "2"},
+			want:   2 * time.Second,
+			wantOK: true,
+		},
+		{
+			name:   "negative seconds",
+			header: map[string]string{"Retry-After": "-5"},
+		},
+		{
+			name:   "huge seconds",
+			header: map[string]string{"Retry-After": "1e30"},
+			want:   math.MaxInt64,
+			wantOK: true,
+		},
+		{
+			name:   "invalid",
+			header: map[string]string{"Retry-After": "soon"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			header := http.Header{}
+			for key, value := range tt.header {
+				header.Set(key, value)
+			}
+
+			got, ok := parseRetryAfter(header, now)
+			if got != tt.want || ok != tt.wantOK {
+				t.Errorf("got %v, %v, want %v, %v", got, ok, tt.want, tt.wantOK)
+			}
+		})
+	}
+}
+
+func TestRetryPolicyRetryAfter(t *testing.T) {
+	tests := []struct {
+		name   string
+		policy RetryPolicy
+		wait   time.Duration
+		want   time.Duration
+	}{
+		{
+			name:   "under the cap",
+			policy: RetryPolicy{MaxRetryAfter: time.Minute},
+			wait:   10 * time.Second,
+			want:   10 * time.Second,
+		},
+		{
+			name:   "capped at MaxRetryAfter",
+			policy: RetryPolicy{MaxBackoff: time.Second, MaxRetryAfter: time.Minute},
+			wait:   time.Hour,
+			want:   time.Minute,
+		},
+		{
+			name:   "capped at MaxBackoff",
+			policy: RetryPolicy{MaxBackoff: 30 * time.Second},
+			wait:   time.Hour,
+			want:   30 * time.Second,
+		},
+		{
+			name:   "no cap",
+			policy: RetryPolicy{},
+			wait:   time.Hour,
+			want:   time.Hour,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.policy.retryAfter(tt.wait); got != tt.want {
+				t.Errorf("got %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestIsRetryableError(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{
+			name: "nil",
+			err:  nil,
+		},
+		{
+			name: "context canceled",
+			err:  context.Canceled,
+		},
+		{
+			name: "deadline exceeded",
+			err:  fmt.Errorf("request: %w", context.DeadlineExceeded),
+		},
+		{
+			name: "OpenAI rate limit",
+			err:  &openai.APIError{HTTPStatusCode: http.StatusTooManyRequests},
+			want: true,
+		},
+		{
+			name: "OpenAI bad request",
+			err:  &openai.APIError{HTTPStatusCode: http.StatusBadRequest},
+		},
+		{
+			name: "OpenAI unavailable",
+			err:  &openai.RequestError{HTTPStatusCode: http.StatusServiceUnavailable},
+			want: true,
+		},
+		{
+			name: "Anthropic overloaded status",
+			err:  &anthropic.RequestError{StatusCode: 529},
+			want: true,
+		},
+		{
+			name: "Anthropic rate limit type",
+			err:  &anthropic.APIError{Type: anthropic.ErrTypeRateLimit},
+			want: true,
+		},
+		{
+			name: "Anthropic invalid request type",
+			err:  &anthropic.APIError{Type: anthropic.ErrTypeInvalidRequest},
+		},
+		{
+			name: "Cohere server error",
+			err:  cohereCore.NewAPIError(http.StatusInternalServerError, errors.New("internal")),
+			want: true,
+		},
+		{
+			name: "Cohere unauthorized",
+			err:  cohereCore.NewAPIError(http.StatusUnauthorized, errors.New("unauthorized")),
+		},
+		{
+			name: "wrapped in a ProviderError",
+			err:  newProviderError(ProviderOpenAI, &openai.APIError{HTTPStatusCode: http.StatusBadGateway}),
+			want: true,
+		},
+		{
+			name: "network timeout",
+			err:  &net.DNSError{IsTimeout: true},
+			want: true,
+		},
+		{
+			name: "network error",
+			err:  &net.DNSError{IsNotFound: true},
+		},
+		{
+			name: "other error",
+			err:  errors.New("boom"),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := IsRetryableError(tt.err); got != tt.want {
+				t.Errorf("got %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -13,6 +13,9 @@ type Schema struct {
 	String string
 
 	Functions []FunctionDefinition
+
+	// Functions take the whole StreamWrapper instead of one of its elements
+	streamWrapper bool
 }
 
 type Function struct {
@@ -72,5 +75,31 @@ func ToFunctionSchema(tType reflect.Type, tSchema *jsonschema.Schema) []Function
 }
 
 func (s *Schema) NameFromRef() string {
-	return strings.Split(s.Ref, "/")[2] // ex: '#/$defs/MyStruct'
+	return strings.TrimPrefix(s.Ref, "#/$defs/") // ex: '#/$defs/MyStruct'
+}
+
+// Name of the response: the struct name, or for a list of structs the name
+// of the elements followed by "List", ex: "PersonList"
+func (s *Schema) responseName() string {
+	switch {
+	case s.Ref != "":
+		return s.NameFromRef()
+	case s.Type == "array" && s.Items != nil && s.Items.Ref != "":
+		return (&Schema{Schema: s.Items}).NameFromRef() + "List"
+	default:
+		return "Response"
+	}
+}
+
+// Schema of the response itself, its references point into s.Definitions
+func (s *Schema) responseDefinition() *jsonschema.Schema {
+	if definition, ok := s.Definitions[s.NameFromRef()]; ok && s.Ref != "" {
+		return definition
+	}
+
+	definition := *s.Schema
+	definition.Version = ""
+	definition.ID = ""
+	definition.Definitions = nil
+	return &definition
 }
@@ -0,0 +1,197 @@
+package instructor
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+)
+
+type SSEEventType = string
+
+const (
+	// data is the JSON of a response
+	SSEEventItem SSEEventType = "item"
+	// data is an SSEError, for a dropped element or the error ending the stream
+	SSEEventError SSEEventType = "error"
+	// data is the UsageSum of the stream
+	SSEEventUsage SSEEventType = "usage"
+	// last event, written once the stream ended
+	SSEEventDone SSEEventType = "done"
+)
+
+// SSEError is the data of an error event
+type SSEError struct {
+	Message string `json:"message"`
+	// Position of the dropped element in the model output, nil for the
+	// error ending the stream
+	Index *int `json:"index,omitempty"`
+}
+
+func (e *SSEError) Error() string {
+	return e.Message
+}
+
+// WriteSSE relays the stream to w as Server-Sent Events: an item event per
+// response, then an error event per dropped element and for the error ending
+// the stream, a usage event and a done event. Every event is flushed. It
+// returns early with the context error once ctx, usually the context of the
+// request, is cancelled; start the stream with the same context so the
+// provider request is cancelled too.
+func WriteSSE[T any](ctx context.Context, w http.ResponseWriter, stream *Stream[T]) error {
+	header := w.Header()
+	header.Set("Content-Type", "text/event-stream")
+	header.Set("Cache-Control", "no-cache")
+	header.Set("Connection", "keep-alive")
+
+	flusher := http.NewResponseController(w)
+
+	write := func(event SSEEventType, data any) error {
+		dataJSON, err := json.Marshal(data)
+		if err != nil {
+			return err
+		}
+		if _, err = fmt.Fprintf(w, "event: %s\ndata: %s\n\n", event, dataJSON); err != nil {
+			return err
+		}
+		if err = flusher.Flush(); errors.Is(err, http.ErrNotSupported) {
+			// events are delivered when the handler returns
+			return nil
+		}
+		return err
+	}
+
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case item, ok := <-stream.Items():
+			if !ok {
+				return writeSSEEnd(stream, write)
+			}
+			if err := write(SSEEventItem, item); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+func writeSSEEnd[T any](stream *Stream[T], write func(event SSEEventType, data any) error) error {
+	for _, dropped := range stream.Dropped() {
+		if err := write(SSEEventError, &SSEError{Message: dropped.Err.Error(), Index: toPtr(dropped.Index)}); err != nil {
+			return err
+		}
+	}
+
+	if err := stream.Err(); err != nil {
+		if err := write(SSEEventError, &SSEError{Message: err.Error()}); err != nil {
+			return err
+		}
+	}
+
+	if err := write(SSEEventUsage, stream.Usage()); err != nil {
+		return err
+	}
+
+	return write(SSEEventDone, struct{}{})
+}
+
+// SSEEvent is an event written by WriteSSE, with the field of its type set
+type SSEEvent[T any] struct {
+	Type  SSEEventType
+	Item  T
+	Error *SSEError
+	Usage *UsageSum
+}
+
+// SSEDecoder reads the events written by WriteSSE from a response body
+type SSEDecoder[T any] struct {
+	reader *bufio.Reader
+	done   bool
+}
+
+func NewSSEDecoder[T any](body io.Reader) *SSEDecoder[T] {
+	return &SSEDecoder[T]{reader: bufio.NewReader(body)}
+}
+
+// Next returns the next event, the done event included. After the done
+// event, or when the body ends, it returns io.EOF.
+func (d *SSEDecoder[T]) Next() (*SSEEvent[T], error) {
+	if d.done {
+		return nil, io.EOF
+	}
+
+	for {
+		eventType, data, err := d.readEvent()
+		if err != nil {
+			return nil, err
+		}
+
+		event := &SSEEvent[T]{Type: eventType}
+
+		switch eventType {
+		case SSEEventItem:
+			err = json.Unmarshal([]byte(data), &event.Item)
+		case SSEEventError:
+			event.Error = &SSEError{}
+			err = json.Unmarshal([]byte(data), event.Error)
+		case SSEEventUsage:
+			event.Usage = &UsageSum{}
+			err = json.Unmarshal([]byte(data), event.Usage)
+		case SSEEventDone:
+			d.done = true
+		default:
+			// not written by WriteSSE, ex: a keep-alive
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to decode %s event: %w", eventType, err)
+		}
+
+		return event, nil
+	}
+}
+
+// Reads the lines of an event up to the blank line ending it
+func (d *SSEDecoder[T]) readEvent() (eventType SSEEventType, data string, err error) {
+	dataLines := []string{}
+	hasFields := false
+
+	for {
+		line, readErr := d.reader.ReadString('\n')
+		line = strings.TrimRight(line, "\r\n")
+
+		if line == "" {
+			if hasFields {
+				return eventType, strings.Join(dataLines, "\n"), nil
+			}
+			if readErr != nil {
+				return "", "", readErr
+			}
+			continue
+		}
+
+		field, value, _ := strings.Cut(line, ":")
+		value = strings.TrimPrefix(value, " ")
+
+		switch field {
+		case "":
+			// comment
+			continue
+		case "event":
+			eventType = value
+		case "data":
+			dataLines = append(dataLines, value)
+		}
+		hasFields = true
+
+		if readErr != nil {
+			// body ended without the blank line
+			return eventType, strings.Join(dataLines, "\n"), nil
+		}
+	}
+}
@@ -0,0 +1,136 @@
+package instructor
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+type sseTestItem struct {
+	A int `json:"a"`
+}
+
+// Reads every event of the body, returning the error ending it
+func decodeAll(body io.Reader) ([]SSEEvent[sseTestItem], error) {
+	decoder := NewSSEDecoder[sseTestItem](body)
+	events := []SSEEvent[sseTestItem]{}
+
+	for {
+		event, err := decoder.Next()
+		if err != nil {
+			return events, err
+		}
+		events = append(events, *event)
+	}
+}
+
+func TestSSERoundTrip(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		stream, items := newStream[*sseTestItem](func() {})
+
+		go func() {
+			stream.send(r.Context(), items, &sseTestItem{A: 1})
+			stream.drop(DroppedElement{Index: 1, Err: errors.New("bad element")})
+			stream.send(r.Context(), items, &sseTestItem{A: 2})
+			stream.addUsage(UsageSum{InputTokens: 1, OutputTokens: 2, TotalTokens: 3})
+			stream.finish(items, errors.New("stream failed"))
+		}()
+
+		if err := WriteSSE(r.Context(), w, stream); err != nil {
+			t.Errorf("got error %v writing the events", err)
+		}
+	}))
+	defer server.Close()
+
+	resp, err := http.Get(server.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+
+	if got := resp.Header.Get("Content-Type"); got != "text/event-stream" {
+		t.Errorf("got Content-Type %q, want %q", got, "text/event-stream")
+	}
+
+	events, err := decodeAll(resp.Body)
+	if err != io.EOF {
+		t.Errorf("got error %v, want io.EOF", err)
+	}
+
+	want := []SSEEvent[sseTestItem]{
+		{Type: SSEEventItem, Item: sseTestItem{A: 1}},
+		{Type: SSEEventItem, Item: sseTestItem{A: 2}},
+		{Type: SSEEventError, Error: &SSEError{Message: "bad element", Index: toPtr(1)}},
+		{Type: SSEEventError, Error: &SSEError{Message: "stream failed"}},
+		{Type: SSEEventUsage, Usage: &UsageSum{InputTokens: 1, OutputTokens: 2, TotalTokens: 3}},
+		{Type: SSEEventDone},
+	}
+	if !reflect.DeepEqual(events, want) {
+		t.Errorf("got %+v, want %+v", events, want)
+	}
+}
+
+func TestSSEDecoder(t *testing.T) {
+	tests := []struct {
+		name string
+		body string
+		want []SSEEvent[sseTestItem]
+	}{
+		{
+			name: "multi-line data",
+			body: "event: item\ndata: {\"a\":\ndata: 1}\n\n",
+			want: []SSEEvent[sseTestItem]{{Type: SSEEventItem, Item: sseTestItem{A: 1}}},
+		},
+		{
+			name: "comments",
+			body: ": keep-alive\n\nevent: item\n: between fields\ndata: {\"a\": 2}\n\n",
+			want: []SSEEvent[sseTestItem]{{Type: SSEEventItem, Item: sseTestItem{A: 2}}},
+		},
+		{
+			name: "CRLF",
+			body: "event: item\r\ndata: {\"a\": 3}\r\n\r\nevent: done\r\ndata: {}\r\n\r\n",
+			want: []SSEEvent[sseTestItem]{
+				{Type: SSEEventItem, Item: sseTestItem{A: 3}},
+				{Type: SSEEventDone},
+			},
+		},
+		{
+			name: "body ending without a blank line",
+			body: "event: item\ndata: {\"a\": 4}",
+			want: []SSEEvent[sseTestItem]{{Type: SSEEventItem, Item: sseTestItem{A: 4}}},
+		},
+		{
+			name: "events after done",
+			body: "event: done\ndata: {}\n\nevent: item\ndata: {\"a\": 5}\n\n",
+			want: []SSEEvent[sseTestItem]{{Type: SSEEventDone}},
+		},
+		{
+			name: "unknown events",
+			body: "event: ping\ndata: x\n\ndata: no type\n\nevent: usage\ndata: {\"total_tokens\": 6}\n\n",
+			want: []SSEEvent[sseTestItem]{{Type: SSEEventUsage, Usage: &UsageSum{TotalTokens: 6}}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			events, err := decodeAll(strings.NewReader(tt.body))
+			if err != io.EOF {
+				t.Errorf("got error %v, want io.EOF", err)
+			}
+			if !reflect.DeepEqual(events, tt.want) {
+				t.Errorf("got %+v, want %+v", events, tt.want)
+			}
+		})
+	}
+}
+
+func TestSSEDecoderInvalidData(t *testing.T) {
+	_, err := decodeAll(strings.NewReader("event: item\ndata: {\"a\": \"x\"}\n\n"))
+	if err == nil || err == io.EOF {
+		t.Errorf("got error %v, want a decode error", err)
+	}
+}
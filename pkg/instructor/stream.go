@@ -0,0 +1,114 @@
+package instructor
+
+import (
+	"context"
+	"sync"
+)
+
+// Stream delivers the responses of a streaming extraction on Items. Once the
+// channel is closed, Err reports why the stream ended early (nil when the
+// model output was read to the end), Dropped lists the elements of the
+// output that failed to decode or validate and Usage the tokens used. Call
+// Close, or cancel the context of the request, to stop reading early.
+type Stream[T any] struct {
+	items <-chan T
+	// cancels the context of the goroutines producing the stream
+	cancel context.CancelFunc
+
+	mu      sync.Mutex
+	err     error
+	dropped []DroppedElement
+	usage   UsageSum
+}
+
+func newStream[T any](cancel context.CancelFunc) (*Stream[T], chan<- T) {
+	items := make(chan T)
+	return &Stream[T]{items: items, cancel: cancel}, items
+}
+
+// Items returns the channel of responses, closed at the end of the stream
+func (s *Stream[T]) Items() <-chan T {
+	return s.items
+}
+
+// Err returns the error that ended the stream, ex: a *ProviderError or the
+// context error. Call it once Items is closed.
+func (s *Stream[T]) Err() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.err
+}
+
+// Dropped returns the elements of the model output that were skipped, with
+// the *DecodeError or *ValidationError explaining why
+func (s *Stream[T]) Dropped() []DroppedElement {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]DroppedElement{}, s.dropped...)
+}
+
+// Usage returns the tokens used by the stream, as reported by the provider.
+// Call it once Items is closed, the totals are only complete then.
+func (s *Stream[T]) Usage() UsageSum {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.usage
+}
+
+func (s *Stream[T]) addUsage(usage UsageSum) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.usage.InputTokens += usage.InputTokens
+	s.usage.OutputTokens += usage.OutputTokens
+	s.usage.TotalTokens += usage.TotalTokens
+}
+
+func (s *Stream[T]) drop(dropped DroppedElement) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.dropped = append(s.dropped, dropped)
+}
+
+// Close stops the stream early, ex: after breaking out of a loop over Items.
+// The provider request is cancelled and Items closed, Err then reports
+// context.Canceled. Closing an ended stream has no effect.
+func (s *Stream[T]) Close() {
+	s.cancel()
+}
+
+// Records the error, if any, and closes the items channel
+func (s *Stream[T]) finish(items chan<- T, err error) {
+	s.mu.Lock()
+	s.err = err
+	s.mu.Unlock()
+
+	close(items)
+	s.cancel()
+}
+
+func (s *Stream[T]) send(ctx context.Context, items chan<- T, item T) bool {
+	select {
+	case <-ctx.Done():
+		return false
+	case items <- item:
+		return true
+	}
+}
+
+// streamChunk is a piece of the model output streamed by a provider, a
+// chunk with Err is the last one of a failed stream
+type streamChunk struct {
+	Text string
+	// Tokens reported by the provider, added to the usage of the stream
+	Usage *UsageSum
+	Err   error
+}
+
+func sendChunk(ctx context.Context, ch chan<- streamChunk, chunk streamChunk) bool {
+	select {
+	case <-ctx.Done():
+		return false
+	case ch <- chunk:
+		return true
+	}
+}
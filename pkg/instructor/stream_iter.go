@@ -0,0 +1,35 @@
+//go:build go1.23
+
+package instructor
+
+import "iter"
+
+// All returns an iterator over the responses, then over the errors of the
+// dropped elements and the error ending the stream, if any. Breaking out of
+// the loop closes the stream. The stream is read once: a second loop only
+// yields the errors again, it does not request the responses again. Usage
+// has the tokens used once the loop ends.
+func (s *Stream[T]) All() iter.Seq2[T, error] {
+	return func(yield func(T, error) bool) {
+		// stops the producer goroutines when the loop exits early
+		defer s.Close()
+
+		var zero T
+
+		for item := range s.Items() {
+			if !yield(item, nil) {
+				return
+			}
+		}
+
+		for _, dropped := range s.Dropped() {
+			if !yield(zero, dropped.Err) {
+				return
+			}
+		}
+
+		if err := s.Err(); err != nil {
+			yield(zero, err)
+		}
+	}
+}
@@ -0,0 +1,72 @@
+//go:build go1.23
+
+package instructor
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+)
+
+type streamTestItem struct {
+	A int `json:"a"`
+}
+
+func TestStreamAll(t *testing.T) {
+	ch := make(chan streamChunk, 1)
+	ch <- streamChunk{Text: `{"items": [{"a": 1}, {"a": "x"}, {"a": 2}`}
+	close(ch)
+
+	stream, err := streamOf[streamTestItem](context.Background(), func(ctx context.Context) (*Stream[any], error) {
+		ctx, cancel := context.WithCancel(ctx)
+		return parseStream(ctx, cancel, ch, nil, reflect.TypeOf(streamTestItem{})), nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	items := []streamTestItem{}
+	errs := []error{}
+	for item, err := range stream.All() {
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		items = append(items, item)
+	}
+
+	if want := []streamTestItem{{A: 1}, {A: 2}}; !reflect.DeepEqual(items, want) {
+		t.Errorf("got items %v, want %v", items, want)
+	}
+	if len(errs) != 1 {
+		t.Fatalf("got errors %v, want the error of the dropped element", errs)
+	}
+	var decodeErr *DecodeError
+	if !errors.As(errs[0], &decodeErr) {
+		t.Errorf("got error %T, want a *DecodeError", errs[0])
+	}
+}
+
+func TestStreamAllBreak(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	ch := make(chan streamChunk)
+	go func() {
+		defer close(ch)
+		sendChunk(ctx, ch, streamChunk{Text: `{"items": [`})
+		for sendChunk(ctx, ch, streamChunk{Text: `{"a": 1},`}) {
+		}
+	}()
+
+	stream := parseStream(ctx, cancel, ch, nil, reflect.TypeOf(streamTestItem{}))
+	for range stream.All() {
+		break
+	}
+
+	// the producer stops, closing the items channel
+	for range stream.Items() {
+	}
+	if err := stream.Err(); !errors.Is(err, context.Canceled) {
+		t.Errorf("got error %v, want context.Canceled", err)
+	}
+}
@@ -0,0 +1,69 @@
+package instructor
+
+import (
+	"context"
+
+	cohere "github.com/cohere-ai/cohere-go/v2"
+	anthropic "github.com/liushuangls/go-anthropic/v2"
+	openai "github.com/sashabaranov/go-openai"
+)
+
+// CreateChatCompletionStreamOf streams the responses as T. Call Close on the
+// stream to stop reading early; on Go 1.23 and later, range over its All
+// iterator instead, breaking out of the loop closes the stream.
+func CreateChatCompletionStreamOf[T any](ctx context.Context, client *InstructorOpenAI, request openai.ChatCompletionRequest, opts ...Options) (*Stream[T], error) {
+	return streamOf[T](ctx, func(ctx context.Context) (*Stream[any], error) {
+		return client.CreateChatCompletionStream(ctx, request, *new(T), opts...)
+	})
+}
+
+// CreateMessagesStreamOf streams the responses as T, see CreateChatCompletionStreamOf
+func CreateMessagesStreamOf[T any](ctx context.Context, client *InstructorAnthropic, request anthropic.MessagesStreamRequest, opts ...Options) (*Stream[T], error) {
+	return streamOf[T](ctx, func(ctx context.Context) (*Stream[any], error) {
+		return client.CreateMessagesStream(ctx, request, *new(T), opts...)
+	})
+}
+
+// ChatStreamOf streams the responses as T, see CreateChatCompletionStreamOf
+func ChatStreamOf[T any](ctx context.Context, client *InstructorCohere, request *cohere.ChatStreamRequest, opts ...Options) (*Stream[T], error) {
+	return streamOf[T](ctx, func(ctx context.Context) (*Stream[any], error) {
+		return client.ChatStream(ctx, request, *new(T), opts...)
+	})
+}
+
+func streamOf[T any](ctx context.Context, start func(ctx context.Context) (*Stream[any], error)) (*Stream[T], error) {
+
+	// cancelled by Close on the typed stream, stopping the untyped one too
+	ctx, cancel := context.WithCancel(ctx)
+
+	stream, err := start(ctx)
+	if err != nil {
+		cancel()
+		return nil, err
+	}
+
+	typed, items := newStream[T](cancel)
+
+	go func() {
+		var err error
+		defer func() {
+			typed.addUsage(stream.Usage())
+			typed.finish(items, err)
+		}()
+
+		for item := range stream.Items() {
+			if !typed.send(ctx, items, *item.(*T)) {
+				err = ctx.Err()
+				return
+			}
+		}
+
+		for _, dropped := range stream.Dropped() {
+			typed.drop(dropped)
+		}
+
+		err = stream.Err()
+	}()
+
+	return typed, nil
+}
@@ -0,0 +1,154 @@
+package instructor
+
+import (
+	"encoding/json"
+	"strings"
+)
+
+// toolCallStream assembles the argument fragments of streamed tool calls
+// into a StreamWrapper, with one element per (parallel) tool call. The
+// arguments of one tool call are forwarded as they arrive, fragments of the
+// other tool calls are held until the forwarded arguments are complete.
+//
+// With wrapped set, the arguments of every tool call are a StreamWrapper
+// themselves (see streamWrapperFunction): their elements are forwarded once
+// complete, merged into a single items array, so extra calls of the items
+// tool are not lost.
+type toolCallStream struct {
+	wrapped bool
+	// tool call indexes in the order they were first seen
+	order []int
+	// arguments received so far per tool call index
+	arguments map[int]*strings.Builder
+	// position in order of the forwarded tool call, -1 before the first one
+	current int
+	// bytes of the arguments of the forwarded tool call already forwarded
+	forwarded int
+
+	// wrapped: elements of the arguments of the forwarded tool call
+	scanner *jsonElementScanner
+	// wrapped: elements forwarded so far, over all tool calls
+	elements int
+	// wrapped: an element was cut off, the items array is left open so the
+	// parser reports it
+	truncated bool
+}
+
+func newToolCallStream(wrapped bool) *toolCallStream {
+	return &toolCallStream{
+		wrapped:   wrapped,
+		arguments: map[int]*strings.Builder{},
+		current:   -1,
+	}
+}
+
+func (s *toolCallStream) started() bool {
+	return s.current != -1
+}
+
+// Adds a fragment of the arguments of the tool call at index, returning the
+// text to forward
+func (s *toolCallStream) add(index int, fragment string) string {
+	text := new(strings.Builder)
+
+	if !s.started() {
+		text.WriteString("{" + WRAPPER_END)
+		s.current = 0
+	}
+
+	arguments, ok := s.arguments[index]
+	if !ok {
+		arguments = new(strings.Builder)
+		s.arguments[index] = arguments
+		s.order = append(s.order, index)
+	}
+	arguments.WriteString(fragment)
+
+	s.forward(text, false)
+
+	return text.String()
+}
+
+// Forwards the new arguments of the current tool call, moving on to the next
+// tool call once the current arguments are complete JSON (or all are, at the
+// end of the stream)
+func (s *toolCallStream) forward(text *strings.Builder, end bool) {
+	for s.current < len(s.order) && !s.truncated {
+		arguments := s.arguments[s.order[s.current]].String()
+
+		if s.wrapped {
+			s.forwardElements(text, arguments[s.forwarded:])
+		} else {
+			text.WriteString(arguments[s.forwarded:])
+		}
+		s.forwarded = len(arguments)
+
+		last := s.current == len(s.order)-1
+		if last && !end {
+			return
+		}
+		if !end && !json.Valid([]byte(arguments)) {
+			return
+		}
+
+		if s.wrapped {
+			s.endElements(text)
+		} else if !last {
+			text.WriteString(",")
+		}
+		s.current++
+		s.forwarded = 0
+	}
+}
+
+// Forwards the elements of the current StreamWrapper completed by the fragment
+func (s *toolCallStream) forwardElements(text *strings.Builder, fragment string) {
+	if s.scanner == nil {
+		s.scanner = newJSONElementScanner()
+	}
+
+	s.scanner.write(fragment)
+	for element, found := s.scanner.next(); found; element, found = s.scanner.next() {
+		s.writeElement(text, element)
+	}
+}
+
+// Forwards the last elements of the current StreamWrapper, once its tool call
+// is complete or the stream ended
+func (s *toolCallStream) endElements(text *strings.Builder) {
+	if s.scanner == nil {
+		return
+	}
+
+	s.scanner.end()
+	s.forwardElements(text, "")
+
+	if pending := s.scanner.pending(); pending != "" {
+		s.writeElement(text, pending)
+		s.truncated = true
+	}
+	s.scanner = nil
+}
+
+func (s *toolCallStream) writeElement(text *strings.Builder, element string) {
+	if s.elements > 0 {
+		text.WriteString(",")
+	}
+	text.WriteString(element)
+	s.elements++
+}
+
+// Returns the text closing the StreamWrapper once the stream ended
+func (s *toolCallStream) end() string {
+	if !s.started() {
+		return ""
+	}
+
+	text := new(strings.Builder)
+	s.forward(text, true)
+	if !s.truncated {
+		text.WriteString("]}")
+	}
+
+	return text.String()
+}
@@ -0,0 +1,81 @@
+package instructor
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestToolCallStream(t *testing.T) {
+	type delta struct {
+		index    int
+		fragment string
+	}
+
+	tests := []struct {
+		name    string
+		wrapped bool
+		deltas  []delta
+		// text forwarded per delta, then by end()
+		want []string
+	}{
+		{
+			name: "no tool calls",
+			want: []string{""},
+		},
+		{
+			name:   "one tool call",
+			deltas: []delta{{0, `{"na`}, {0, `me": "Ann"}`}},
+			want:   []string{`{"items": [{"na`, `me": "Ann"}`, `]}`},
+		},
+		{
+			name: "parallel tool calls",
+			deltas: []delta{
+				{0, `{"name":`},
+				{1, `{"name": "Bo"}`},
+				{0, ` "Ann"}`},
+				{2, `{"name": "Cy"}`},
+			},
+			want: []string{`{"items": [{"name":`, ``, ` "Ann"},{"name": "Bo"}`, `,{"name": "Cy"}`, `]}`},
+		},
+		{
+			name: "cut off tool call",
+			deltas: []delta{
+				{0, `{"name": "Ann"}`},
+				{1, `{"name": "B`},
+			},
+			want: []string{`{"items": [{"name": "Ann"}`, `,{"name": "B`, `]}`},
+		},
+		{
+			name:    "wrapped",
+			wrapped: true,
+			deltas: []delta{
+				{0, `{"items": [{"a": 1}, {"a"`},
+				{1, `{"items": [{"a": 3}]}`},
+				{0, `: 2}]}`},
+			},
+			want: []string{`{"items": [{"a": 1}`, ``, `,{"a": 2},{"a": 3}`, `]}`},
+		},
+		{
+			name:    "wrapped cut off element",
+			wrapped: true,
+			deltas:  []delta{{0, `{"items": [{"a": 1}, {"a": 2`}},
+			want:    []string{`{"items": [{"a": 1}`, `,{"a": 2`},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := newToolCallStream(tt.wrapped)
+
+			got := []string{}
+			for _, d := range tt.deltas {
+				got = append(got, s.add(d.index, d.fragment))
+			}
+			got = append(got, s.end())
+
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
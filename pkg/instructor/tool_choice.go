@@ -0,0 +1,25 @@
+package instructor
+
+import "fmt"
+
+// Name of the tool the model must call: the response type of a struct
+// response, the StreamWrapper tool of a stream of non-struct elements. Empty
+// when the response is made of several tool calls, ex: the elements of a list
+// or of a stream, the model must then call at least one of the tools.
+func forcedToolName(schema *Schema) string {
+	if schema.streamWrapper {
+		return schema.Functions[0].Name
+	}
+	if schema.Ref != "" {
+		return schema.NameFromRef()
+	}
+	return ""
+}
+
+// Instruction to call the tools, for Cohere whose chat API has no tool_choice
+func toolChoicePrompt(schema *Schema) string {
+	if name := forcedToolName(schema); name != "" {
+		return fmt.Sprintf("\nAlways respond by calling the %s tool, never with plain text.\n", name)
+	}
+	return "\nAlways respond by calling the tools, once per item of the response, never with plain text.\n"
+}
@@ -0,0 +1,44 @@
+package instructor
+
+import (
+	"reflect"
+	"testing"
+)
+
+type toolChoiceTestPerson struct {
+	Name string `json:"name"`
+}
+
+func TestForcedToolName(t *testing.T) {
+	tests := []struct {
+		name         string
+		responseType any
+		stream       bool
+		want         string
+	}{
+		{name: "struct", responseType: toolChoiceTestPerson{}, want: "toolChoiceTestPerson"},
+		{name: "pointer to struct", responseType: &toolChoiceTestPerson{}, want: "toolChoiceTestPerson"},
+		{name: "slice", responseType: []toolChoiceTestPerson{}},
+		{name: "stream of structs", responseType: toolChoiceTestPerson{}, stream: true},
+		{name: "stream of strings", responseType: "", stream: true, want: "items"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var schema *Schema
+			var err error
+			if tt.stream {
+				schema, err = newStreamSchema(reflect.TypeOf(tt.responseType))
+			} else {
+				schema, err = NewSchema(reflect.TypeOf(tt.responseType))
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if got := forcedToolName(schema); got != tt.want {
+				t.Errorf("got %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -1,6 +1,8 @@
 package instructor
 
 import (
+	"reflect"
+	"slices"
 	"strings"
 )
 
@@ -12,47 +14,17 @@ func prepend[T any](to []T, from T) []T {
 	return append([]T{from}, to...)
 }
 
-func findMatchingBracket(json *string, start int) int {
-	stack := []int{}
-	openBracket := rune('{')
-	closeBracket := rune('}')
-
-	for i := start; i < len(*json); i++ {
-		if rune((*json)[i]) == openBracket {
-			stack = append(stack, i)
-		} else if rune((*json)[i]) == closeBracket {
-			if len(stack) == 0 {
-				return -1 // Unbalanced brackets
-			}
-			stack = stack[:len(stack)-1]
-			if len(stack) == 0 {
-				return i // Found the matching bracket
-			}
-		}
-	}
-
-	return -1 // Unbalanced brackets
+// Appends to a new backing array when to is full, so appending to a request
+// never writes into the caller's backing array
+func appendCopy[T any](to []T, from ...T) []T {
+	return append(slices.Clip(to), from...)
 }
 
-func getFirstFullJSONElement(json *string) (element string, remaining string) {
-	matchingBracketIdx := findMatchingBracket(json, 0)
-
-	if matchingBracketIdx == -1 {
-		return "", *json
+func indirectType(t reflect.Type) reflect.Type {
+	for t != nil && t.Kind() == reflect.Pointer {
+		t = t.Elem()
 	}
-
-	element = (*json)[:matchingBracketIdx+1]
-	remaining = ""
-
-	if matchingBracketIdx+1 < len(*json) {
-		remaining = (*json)[matchingBracketIdx+1:]
-
-		if (*json)[matchingBracketIdx+1] == ',' {
-			remaining = (*json)[matchingBracketIdx+2:]
-		}
-	}
-
-	return element, remaining
+	return t
 }
 
 // Removes any prefixes before the JSON (like "Sure, here you go:")
@@ -0,0 +1,356 @@
+package instructor
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+
+	"github.com/go-playground/validator/v10"
+)
+
+// Validatable is implemented by response types that check their own
+// content after unmarshalling, ex: that a total matches the sum of its items.
+// Validate() runs along with the `validate` struct tags, once validation is
+// enabled with WithValidation.
+type Validatable interface {
+	Validate() error
+}
+
+// FieldError is a validation failure of a single value of the response,
+// addressed by its JSON path, ex: "addresses[1].phone"
+type FieldError struct {
+	// JSON path of the value, empty for the response itself
+	Path string
+	// Human-readable message, ex: "addresses[1].phone must be a valid phone number"
+	Message string
+	// Underlying error, ex: a validator.FieldError or the error of a Validate() method
+	Err error
+}
+
+func (e *FieldError) Error() string {
+	return e.Message
+}
+
+func (e *FieldError) Unwrap() error {
+	return e.Err
+}
+
+// Runs the validator's struct (tag and struct level) validations on the
+// response, or on each element when the response is a slice, array or map
+func validateStruct(validate *validator.Validate, response any) error {
+	return validateStructAt(validate, reflect.ValueOf(response), "")
+}
+
+func validateStructAt(validate *validator.Validate, v reflect.Value, path string) error {
+	for v.Kind() == reflect.Pointer || v.Kind() == reflect.Interface {
+		if v.IsNil() {
+			return nil
+		}
+		v = v.Elem()
+	}
+
+	var errs []error
+
+	switch v.Kind() {
+	case reflect.Struct:
+		var err error
+		if v.CanAddr() {
+			err = validate.Struct(v.Addr().Interface())
+		} else {
+			err = validate.Struct(v.Interface())
+		}
+		return newValidatorFieldErrors(err, v.Type(), path)
+	case reflect.Slice, reflect.Array:
+		for idx := 0; idx < v.Len(); idx++ {
+			errs = append(errs, validateStructAt(validate, v.Index(idx), indexPath(path, idx)))
+		}
+	case reflect.Map:
+		iter := v.MapRange()
+		for iter.Next() {
+			errs = append(errs, validateStructAt(validate, iter.Value(), fieldPath(path, fmt.Sprint(iter.Key()))))
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// Converts validator.ValidationErrors into FieldErrors using JSON paths
+func newValidatorFieldErrors(err error, t reflect.Type, path string) error {
+	var validationErrs validator.ValidationErrors
+	if !errors.As(err, &validationErrs) {
+		return err
+	}
+
+	errs := make([]error, 0, len(validationErrs))
+	for _, fieldErr := range validationErrs {
+		fieldPath := jsonPathFromNamespace(t, fieldErr.StructNamespace(), path)
+		errs = append(errs, &FieldError{
+			Path:    fieldPath,
+			Message: fieldErrorMessage(fieldPath, fieldErr),
+			Err:     fieldErr,
+		})
+	}
+
+	return errors.Join(errs...)
+}
+
+// Maps a validator namespace of Go field names (ex: "User.Addresses[1].Phone")
+// to the JSON path of the value (ex: "addresses[1].phone")
+func jsonPathFromNamespace(t reflect.Type, namespace string, path string) string {
+	segments := strings.Split(namespace, ".")
+
+	// first segment is the name of the validated struct
+	for _, segment := range segments[1:] {
+		t = indirectType(t)
+
+		name, indexes, _ := strings.Cut(segment, "[")
+
+		var field reflect.StructField
+		found := false
+		if t != nil && t.Kind() == reflect.Struct {
+			field, found = t.FieldByName(name)
+		}
+
+		if !found {
+			// type information ran out, keep the Go name
+			path = fieldPath(path, name)
+			t = nil
+		} else {
+			jsonName, inline := jsonFieldName(field)
+			if !inline {
+				path = fieldPath(path, jsonName)
+			}
+			t = field.Type
+		}
+
+		if indexes == "" {
+			continue
+		}
+
+		for _, index := range strings.Split(strings.TrimSuffix(indexes, "]"), "][") {
+			t = indirectType(t)
+			if t != nil && t.Kind() == reflect.Map {
+				path = fieldPath(path, index)
+			} else {
+				path = path + "[" + index + "]"
+			}
+			if t != nil {
+				t = t.Elem()
+			}
+		}
+	}
+
+	return path
+}
+
+// JSON name of a struct field, inline is set for embedded structs without a
+// JSON name whose fields encoding/json flattens into the parent
+func jsonFieldName(field reflect.StructField) (name string, inline bool) {
+	name, _, _ = strings.Cut(field.Tag.Get("json"), ",")
+	if name == "" {
+		if field.Anonymous && indirectType(field.Type).Kind() == reflect.Struct {
+			return "", true
+		}
+		name = field.Name
+	}
+	return name, false
+}
+
+func fieldPath(path string, name string) string {
+	if path == "" {
+		return name
+	}
+	return path + "." + name
+}
+
+func indexPath(path string, idx int) string {
+	return fmt.Sprintf("%s[%d]", path, idx)
+}
+
+func fieldErrorMessage(path string, fieldErr validator.FieldError) string {
+	if path == "" {
+		path = "value"
+	}
+
+	param := fieldErr.Param()
+
+	// min / max / len etc. read as a length for strings and collections
+	unit := ""
+	switch fieldErr.Kind() {
+	case reflect.String:
+		unit = " characters"
+	case reflect.Slice, reflect.Array, reflect.Map:
+		unit = " items"
+	}
+
+	var message string
+
+	switch fieldErr.Tag() {
+	case "required", "required_if", "required_unless", "required_with", "required_without":
+		message = "is required"
+	case "email":
+		message = "must be a valid email address"
+	case "url", "uri", "http_url":
+		message = "must be a valid URL"
+	case "e164":
+		message = "must be a valid phone number"
+	case "uuid", "uuid4":
+		message = "must be a valid UUID"
+	case "datetime":
+		message = fmt.Sprintf("must be a date / time in the format %s", param)
+	case "oneof":
+		message = fmt.Sprintf("must be one of: %s", strings.Join(strings.Fields(param), ", "))
+	case "len":
+		message = fmt.Sprintf("must be exactly %s%s", param, unitOrLong(unit))
+	case "min", "gte":
+		message = fmt.Sprintf("must be at least %s%s", param, unitOrLong(unit))
+	case "max", "lte":
+		message = fmt.Sprintf("must be at most %s%s", param, unitOrLong(unit))
+	case "gt":
+		message = fmt.Sprintf("must be more than %s%s", param, unitOrLong(unit))
+	case "lt":
+		message = fmt.Sprintf("must be less than %s%s", param, unitOrLong(unit))
+	case "eq":
+		message = fmt.Sprintf("must be equal to %s", param)
+	case "ne":
+		message = fmt.Sprintf("must not be equal to %s", param)
+	case "alpha":
+		message = "must contain only letters"
+	case "alphanum":
+		message = "must contain only letters and numbers"
+	case "numeric", "number":
+		message = "must be a number"
+	case "lowercase":
+		message = "must be lowercase"
+	case "uppercase":
+		message = "must be uppercase"
+	case "unique":
+		message = "must contain unique values"
+	default:
+		if param != "" {
+			message = fmt.Sprintf("failed the '%s=%s' validation", fieldErr.Tag(), param)
+		} else {
+			message = fmt.Sprintf("failed the '%s' validation", fieldErr.Tag())
+		}
+	}
+
+	return path + " " + message
+}
+
+func unitOrLong(unit string) string {
+	if unit == "" {
+		return ""
+	}
+	return unit + " long"
+}
+
+// Messages of every failure wrapped in err (joined errors are flattened)
+func errorMessages(err error) []string {
+	if err == nil {
+		return nil
+	}
+
+	switch e := err.(type) {
+	case *ValidationError:
+		return e.Messages()
+	case *DecodeError:
+		var fieldErr *FieldError
+		if errors.As(e.Err, &fieldErr) {
+			return []string{fieldErr.Message}
+		}
+	}
+
+	if joined, ok := err.(interface{ Unwrap() []error }); ok {
+		messages := []string{}
+		for _, e := range joined.Unwrap() {
+			messages = append(messages, errorMessages(e)...)
+		}
+		return messages
+	}
+
+	return []string{err.Error()}
+}
+
+var validatableType = reflect.TypeOf((*Validatable)(nil)).Elem()
+
+// Calls Validate() on the response and on every nested struct, slice / array
+// element and map value implementing Validatable, joining all returned errors
+func validateResponse(response any) error {
+	return validateValue(reflect.ValueOf(response), "")
+}
+
+func validateValue(v reflect.Value, path string) error {
+	switch v.Kind() {
+	case reflect.Invalid:
+		return nil
+	case reflect.Interface:
+		if v.IsNil() {
+			return nil
+		}
+		return validateValue(v.Elem(), path)
+	case reflect.Pointer:
+		if v.IsNil() {
+			return nil
+		}
+	}
+
+	var errs []error
+
+	if err := callValidate(v); err != nil {
+		if path == "" {
+			errs = append(errs, err)
+		} else {
+			errs = append(errs, &FieldError{Path: path, Message: path + ": " + err.Error(), Err: err})
+		}
+	}
+
+	if v.Kind() == reflect.Pointer {
+		// the pointer itself was checked above, continue with what it points to
+		v = v.Elem()
+		if v.Kind() == reflect.Pointer || v.Kind() == reflect.Interface {
+			errs = append(errs, validateValue(v, path))
+			return errors.Join(errs...)
+		}
+	}
+
+	switch v.Kind() {
+	case reflect.Struct:
+		for idx := 0; idx < v.NumField(); idx++ {
+			field := v.Type().Field(idx)
+			if !field.IsExported() {
+				continue
+			}
+			name, inline := jsonFieldName(field)
+			if name == "-" {
+				continue
+			}
+			fieldPathName := path
+			if !inline {
+				fieldPathName = fieldPath(path, name)
+			}
+			errs = append(errs, validateValue(v.Field(idx), fieldPathName))
+		}
+	case reflect.Slice, reflect.Array:
+		for idx := 0; idx < v.Len(); idx++ {
+			errs = append(errs, validateValue(v.Index(idx), indexPath(path, idx)))
+		}
+	case reflect.Map:
+		iter := v.MapRange()
+		for iter.Next() {
+			errs = append(errs, validateValue(iter.Value(), fieldPath(path, fmt.Sprint(iter.Key()))))
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+func callValidate(v reflect.Value) error {
+	if v.Type().Implements(validatableType) {
+		return v.Interface().(Validatable).Validate()
+	}
+	if v.CanAddr() && reflect.PointerTo(v.Type()).Implements(validatableType) {
+		return v.Addr().Interface().(Validatable).Validate()
+	}
+	return nil
+}
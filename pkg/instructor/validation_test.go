@@ -0,0 +1,169 @@
+package instructor
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/go-playground/validator/v10"
+)
+
+type validationTestPhone struct {
+	Number string `json:"number" validate:"required,e164"`
+}
+
+type validationTestAddress struct {
+	City   string                `json:"city" validate:"required"`
+	Phones []validationTestPhone `json:"phones" validate:"dive"`
+}
+
+type validationTestMeta struct {
+	Source string `json:"source" validate:"oneof=web app"`
+}
+
+type validationTestUser struct {
+	validationTestMeta
+	Name      string                           `json:"name" validate:"min=2"`
+	Email     string                           `json:"email_address" validate:"email"`
+	Addresses []*validationTestAddress         `json:"addresses" validate:"dive"`
+	Labels    map[string]validationTestAddress `json:"labels" validate:"dive"`
+	Tags      []string                         `validate:"max=1"`
+}
+
+type validationTestTotal struct {
+	Total int   `json:"total"`
+	Items []int `json:"items"`
+}
+
+func (v validationTestTotal) Validate() error {
+	sum := 0
+	for _, item := range v.Items {
+		sum += item
+	}
+	if sum != v.Total {
+		return errors.New("total must be the sum of the items")
+	}
+	return nil
+}
+
+func TestJSONPathFromNamespace(t *testing.T) {
+	userType := reflect.TypeOf(validationTestUser{})
+
+	tests := []struct {
+		name      string
+		t         reflect.Type
+		namespace string
+		path      string
+		want      string
+	}{
+		{
+			name:      "JSON name",
+			t:         userType,
+			namespace: "validationTestUser.Email",
+			want:      "email_address",
+		},
+		{
+			name:      "Go name without JSON tag",
+			t:         userType,
+			namespace: "validationTestUser.Tags",
+			want:      "Tags",
+		},
+		{
+			name:      "slice of pointers",
+			t:         userType,
+			namespace: "validationTestUser.Addresses[1].Phones[0].Number",
+			want:      "addresses[1].phones[0].number",
+		},
+		{
+			name:      "map key",
+			t:         userType,
+			namespace: "validationTestUser.Labels[home].City",
+			want:      "labels.home.city",
+		},
+		{
+			name:      "embedded struct",
+			t:         userType,
+			namespace: "validationTestUser.validationTestMeta.Source",
+			want:      "source",
+		},
+		{
+			name:      "prefixed by the path of the struct",
+			t:         reflect.TypeOf(validationTestAddress{}),
+			namespace: "validationTestAddress.City",
+			path:      "[2]",
+			want:      "[2].city",
+		},
+		{
+			name:      "unknown field",
+			t:         userType,
+			namespace: "validationTestUser.Missing.Field",
+			want:      "Missing.Field",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := jsonPathFromNamespace(tt.t, tt.namespace, tt.path); got != tt.want {
+				t.Errorf("got %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestValidationErrorMessages(t *testing.T) {
+	tests := []struct {
+		name     string
+		response any
+		want     []string
+	}{
+		{
+			name: "struct tags",
+			response: &validationTestUser{
+				validationTestMeta: validationTestMeta{Source: "mail"},
+				Name:               "A",
+				Email:              "a@example.com",
+				Addresses: []*validationTestAddress{
+					{City: "Oslo"},
+					{Phones: []validationTestPhone{{Number: "123"}}},
+				},
+				Labels: map[string]validationTestAddress{"home": {}},
+				Tags:   []string{"a", "b"},
+			},
+			want: []string{
+				"source must be one of: web, app",
+				"name must be at least 2 characters long",
+				"addresses[1].city is required",
+				"addresses[1].phones[0].number must be a valid phone number",
+				"labels.home.city is required",
+				"Tags must be at most 1 items long",
+			},
+		},
+		{
+			name:     "slice response",
+			response: &[]validationTestAddress{{City: "Oslo"}, {}},
+			want:     []string{"[1].city is required"},
+		},
+		{
+			name:     "Validate() of the response",
+			response: &validationTestTotal{Total: 3, Items: []int{1}},
+			want:     []string{"total must be the sum of the items"},
+		},
+		{
+			name:     "Validate() of elements",
+			response: &[]validationTestTotal{{Total: 1, Items: []int{1}}, {Total: 2}},
+			want:     []string{"[1]: total must be the sum of the items"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := validateStruct(validator.New(), tt.response)
+			err = errors.Join(err, validateResponse(tt.response))
+
+			got := (&ValidationError{Err: err}).Messages()
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got %q, want %q", got, tt.want)
+			}
+		})
+	}
+}